@@ -4,6 +4,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/kemadev/kemutil/pkg/dev"
 	"github.com/spf13/cobra"
 )
@@ -35,7 +37,177 @@ func init() {
 		PreRun: setLogLevel,
 	}
 
+	localPs := &cobra.Command{
+		Use:    "ps",
+		Short:  "List development services",
+		Long:   `List services of the local development environment, along with their state`,
+		RunE:   dev.Ps,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	localLogs := &cobra.Command{
+		Use:    "logs [service...]",
+		Short:  "Show development services logs",
+		Long:   `Show logs of given services of the local development environment, or of all services`,
+		RunE:   dev.Logs,
+		PreRun: setLogLevel,
+	}
+
+	localExec := &cobra.Command{
+		Use:    "exec <service> -- <command> [args...]",
+		Short:  "Run a command in a development service",
+		Long:   `Run a command in a running service container of the local development environment`,
+		RunE:   dev.Exec,
+		Args:   cobra.MinimumNArgs(2),
+		PreRun: setLogLevel,
+	}
+
+	localRestart := &cobra.Command{
+		Use:    "restart <service...>",
+		Short:  "Restart development services",
+		Long:   `Restart given services of the local development environment`,
+		RunE:   dev.Restart,
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: setLogLevel,
+	}
+
+	localBuild := &cobra.Command{
+		Use:    "build [service...]",
+		Short:  "Build development services images",
+		Long:   `Build images of given services of the local development environment, or of all services`,
+		RunE:   dev.Build,
+		PreRun: setLogLevel,
+	}
+
+	localProfiles := &cobra.Command{
+		Use:    "profiles",
+		Short:  "List compose profiles",
+		Long:   `List compose profiles declared by services of the local development environment, along with whether they are enabled`,
+		RunE:   dev.ListProfiles,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	localEnv := &cobra.Command{
+		Use:    "env",
+		Short:  "Output application environment for the host",
+		Long:   `Output the application environment of the local development environment as a .env file or shell exports, with services addresses rewritten to their published ports on the host`,
+		RunE:   dev.Env,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	localRun := &cobra.Command{
+		Use:    "run [-- args...]",
+		Short:  "Run the application on the host",
+		Long:   `Run the application natively on the host with go run, using services of the local development environment`,
+		RunE:   dev.Run,
+		PreRun: setLogLevel,
+	}
+
+	localSnapshot := &cobra.Command{
+		Use:    "snapshot",
+		Short:  "Manage development data snapshots",
+		Long:   `Save, restore, list, and delete snapshots of named volumes of the local development environment`,
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: setLogLevel,
+	}
+
+	localSnapshotSave := &cobra.Command{
+		Use:    "save <name>",
+		Short:  "Save a data snapshot",
+		Long:   `Archive named volumes of the local development environment as a snapshot, replacing any snapshot of the same name`,
+		RunE:   dev.SnapshotSave,
+		Args:   cobra.ExactArgs(1),
+		PreRun: setLogLevel,
+	}
+
+	localSnapshotRestore := &cobra.Command{
+		Use:    "restore <name>",
+		Short:  "Restore a data snapshot",
+		Long:   `Replace the content of named volumes of the local development environment with the ones of a snapshot`,
+		RunE:   dev.SnapshotRestore,
+		Args:   cobra.ExactArgs(1),
+		PreRun: setLogLevel,
+	}
+
+	localSnapshotList := &cobra.Command{
+		Use:    "list",
+		Short:  "List data snapshots",
+		Long:   `List snapshots of the local development environment`,
+		RunE:   dev.SnapshotList,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	localSnapshotDelete := &cobra.Command{
+		Use:    "delete <name>",
+		Short:  "Delete a data snapshot",
+		Long:   `Delete a snapshot of the local development environment`,
+		RunE:   dev.SnapshotDelete,
+		Args:   cobra.ExactArgs(1),
+		PreRun: setLogLevel,
+	}
+
+	localReset := &cobra.Command{
+		Use:    "reset",
+		Short:  "Reset development data",
+		Long:   `Drop named volumes of the local development environment, start services again, apply database migrations and fixtures, and run seed scripts`,
+		RunE:   dev.Reset,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	localDB := &cobra.Command{
+		Use:    "db",
+		Short:  "Manage the development database",
+		Long:   `Apply migrations and fixtures to the local development database, or open a shell on it`,
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: setLogLevel,
+	}
+
+	localDBMigrate := &cobra.Command{
+		Use:    "migrate",
+		Short:  "Apply database migrations",
+		Long:   `Apply pending versioned SQL migrations of tool/dev/db/migrations to the local development database, recording applied versions`,
+		RunE:   dev.DBMigrate,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	localDBSeed := &cobra.Command{
+		Use:    "seed",
+		Short:  "Apply database fixtures",
+		Long:   `Apply pending migrations, then SQL fixtures of tool/dev/db/fixtures, to the local development database`,
+		RunE:   dev.DBSeed,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	localDBShell := &cobra.Command{
+		Use:    "shell",
+		Short:  "Open a database shell",
+		Long:   `Open an interactive psql session on the local development database, within its service container`,
+		RunE:   dev.DBShell,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	localDebugConfig := &cobra.Command{
+		Use:    "debug-config",
+		Short:  "Generate editor debug configuration",
+		Long:   `Generate or update the editor configuration connecting to the debugger of the debug service, with sources mapped to the repository root, leaving other configurations untouched`,
+		RunE:   dev.DebugConfig,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
 	rootCmd.AddCommand(devCmd)
+	devCmd.PersistentFlags().
+		StringArrayVarP(&dev.Files, "file", "f", nil, "Compose file layered over discovered ones, can be repeated")
+	devCmd.PersistentFlags().
+		StringArrayVar(&dev.Profiles, "profile", nil, "Compose profile to enable, replacing configured ones, can be repeated. Defaults to \"dev\"")
 	devCmd.AddCommand(localUp)
 	localUp.PersistentFlags().
 		BoolVar(&dev.Debug, "debugger", false, "Enable debugger startup")
@@ -43,7 +215,78 @@ func init() {
 		BoolVar(&dev.Live, "live", false, "Enable hot reload")
 	localUp.PersistentFlags().
 		BoolVar(&dev.ExportNetrc, "netrc", false, "Export netrc")
+	localUp.PersistentFlags().
+		BoolVar(&dev.Detach, "detach", false, "Start services in the background, and print their URLs")
+	localUp.PersistentFlags().
+		BoolVar(&dev.Wait, "wait", false, "Wait for services to be running, healthy, and listening on their published ports. Implies --detach")
+	localUp.PersistentFlags().
+		DurationVar(&dev.WaitTimeout, "wait-timeout", 2*time.Minute, "How long to wait for services to be ready, used with --wait")
+	localUp.PersistentFlags().
+		BoolVar(&dev.AutoPorts, "auto-ports", false, "Remap published ports already in use to free ones, until \"dev down\"")
 	devCmd.AddCommand(localDown)
 	localDown.PersistentFlags().
 		BoolVar(&dev.Debug, "debugger", false, "Enable debugger startup")
+	devCmd.AddCommand(localPs)
+	localPs.PersistentFlags().
+		BoolVar(&dev.Debug, "debugger", false, "Include debug profile services")
+	devCmd.AddCommand(localLogs)
+	localLogs.PersistentFlags().
+		BoolVar(&dev.Debug, "debugger", false, "Include debug profile services")
+	localLogs.PersistentFlags().
+		BoolVar(&dev.Follow, "follow", false, "Follow logs output")
+	devCmd.AddCommand(localExec)
+	localExec.PersistentFlags().
+		BoolVar(&dev.Debug, "debugger", false, "Include debug profile services")
+	devCmd.AddCommand(localRestart)
+	localRestart.PersistentFlags().
+		BoolVar(&dev.Debug, "debugger", false, "Include debug profile services")
+	devCmd.AddCommand(localBuild)
+	localBuild.PersistentFlags().
+		BoolVar(&dev.Debug, "debugger", false, "Include debug profile services")
+	localBuild.PersistentFlags().
+		BoolVar(&dev.ExportNetrc, "netrc", false, "Export netrc")
+	devCmd.AddCommand(localProfiles)
+	localProfiles.PersistentFlags().
+		BoolVar(&dev.Debug, "debugger", false, "Include debug profile services")
+	devCmd.AddCommand(localEnv)
+	localEnv.PersistentFlags().
+		StringVar(&dev.EnvService, "service", dev.DefaultEnvService, "Service to take application environment from")
+	localEnv.PersistentFlags().
+		StringVar(&dev.EnvFormat, "format", dev.EnvFormatDotenv, "Output format, one of \"dotenv\" or \"shell\"")
+	localEnv.PersistentFlags().
+		StringVarP(&dev.EnvOutput, "output", "o", "", "File to write environment to, instead of the standard output")
+	devCmd.AddCommand(localRun)
+	localRun.PersistentFlags().
+		StringVar(&dev.EnvService, "service", dev.DefaultEnvService, "Service to take application environment from")
+	localRun.PersistentFlags().
+		StringVar(&dev.RunPackage, "package", "", "Package to run, defaults to cmd/<app name> at the repository root")
+	localRun.PersistentFlags().
+		BoolVar(&dev.Live, "live", false, "Rebuild and restart on Go sources and web assets changes, keeping the previous binary running when builds fail")
+	localRun.PersistentFlags().
+		DurationVar(&dev.GracePeriod, "grace-period", 10*time.Second, "How long the application is given to shut down on restarts, used with --live")
+	devCmd.AddCommand(localSnapshot)
+	localSnapshot.AddCommand(localSnapshotSave)
+	localSnapshot.AddCommand(localSnapshotRestore)
+	localSnapshot.AddCommand(localSnapshotList)
+	localSnapshot.AddCommand(localSnapshotDelete)
+	localSnapshot.PersistentFlags().
+		BoolVar(&dev.Debug, "debugger", false, "Include debug profile services")
+	devCmd.AddCommand(localReset)
+	localReset.PersistentFlags().
+		BoolVar(&dev.Debug, "debugger", false, "Include debug profile services")
+	localReset.PersistentFlags().
+		BoolVar(&dev.ExportNetrc, "netrc", false, "Export netrc")
+	localReset.PersistentFlags().
+		BoolVar(&dev.AutoPorts, "auto-ports", false, "Remap published ports already in use to free ones, until \"dev down\"")
+	localReset.PersistentFlags().
+		DurationVar(&dev.WaitTimeout, "wait-timeout", 2*time.Minute, "How long to wait for services to be ready before running seed scripts")
+	devCmd.AddCommand(localDB)
+	localDB.AddCommand(localDBMigrate)
+	localDB.AddCommand(localDBSeed)
+	localDB.AddCommand(localDBShell)
+	localDB.PersistentFlags().
+		StringVar(&dev.EnvService, "service", dev.DefaultEnvService, "Service to take the database connection URL from")
+	devCmd.AddCommand(localDebugConfig)
+	localDebugConfig.PersistentFlags().
+		StringVar(&dev.Editor, "editor", dev.EditorVSCode, "Editor to generate configuration for, one of \"vscode\", \"goland\" or \"nvim-dap\"")
 }
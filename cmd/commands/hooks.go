@@ -0,0 +1,55 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package cmd
+
+import (
+	"github.com/kemadev/kemutil/pkg/hooks"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	hooksCmd := &cobra.Command{
+		Use:    "hooks",
+		Short:  "Manage git hooks",
+		Long:   `Manage git hooks running kemutil workflows, such as CI checks and commit message validation`,
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: setLogLevel,
+	}
+	hooksInstall := &cobra.Command{
+		Use:   "install",
+		Short: "Install git hooks",
+		Long: `Install pre-commit, commit-msg and pre-push hooks in the current repository, honoring core.hooksPath
+
+	Hooks listed in hooks.disabled configuration are skipped`,
+		RunE:   hooks.Install,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+	hooksUninstall := &cobra.Command{
+		Use:    "uninstall",
+		Short:  "Uninstall git hooks",
+		Long:   `Uninstall git hooks installed by kemutil from the current repository`,
+		RunE:   hooks.Uninstall,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+	hooksRun := &cobra.Command{
+		Use:   "run <hook> [args...]",
+		Short: "Run a git hook",
+		Long: `Run a git hook, as invoked by git
+
+	pre-commit runs CI checks on files staged for commit, commit-msg validates conventional commit format, and pre-push runs the full CI`,
+		RunE:      hooks.Run,
+		Args:      cobra.MinimumNArgs(1),
+		ValidArgs: hooks.Hooks,
+		PreRun:    setLogLevel,
+	}
+
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksInstall)
+	hooksInstall.PersistentFlags().
+		BoolVar(&hooks.Force, "force", false, "Replace existing hooks not managed by kemutil, backing them up to <hook>.bak, restored on uninstall")
+	hooksCmd.AddCommand(hooksUninstall)
+	hooksCmd.AddCommand(hooksRun)
+}
@@ -4,11 +4,26 @@
 package cmd
 
 import (
+	"log"
 	"log/slog"
 
+	"github.com/kemadev/kemutil/internal/redact"
 	"github.com/spf13/cobra"
 )
 
+// setupLogger installs the default logger, redacting registered secrets from all records, while
+// keeping the format of the existing default handler.
+func setupLogger() {
+	// The default handler writes through the log package, which slog.SetDefault redirects to the
+	// new handler, looping back to it, so the log package output is restored afterwards
+	writer, flags := log.Writer(), log.Flags()
+
+	slog.SetDefault(slog.New(redact.NewHandler(slog.Default().Handler())))
+
+	log.SetOutput(writer)
+	log.SetFlags(flags)
+}
+
 func setLogLevel(cmd *cobra.Command, _ []string) {
 	if cmd.Flag("debug").Value.String() == "true" {
 		slog.SetLogLoggerLevel(slog.LevelDebug)
@@ -38,6 +38,8 @@ func init() {
 // Execute runs the root command, and thus its subcommands.
 // It is the entry point for the CLI application.
 func Execute() {
+	setupLogger()
+
 	err := rootCmd.Execute()
 	if err != nil {
 		slog.Error("Error executing root command", slog.String("error", err.Error()))
@@ -4,6 +4,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/kemadev/kemutil/pkg/workflow"
 	"github.com/spf13/cobra"
 )
@@ -35,6 +37,88 @@ func init() {
 		PreRun: setLogLevel,
 	}
 
+	workflowListCmd := &cobra.Command{
+		Use:    "list",
+		Short:  "List available checks",
+		Long:   `List checks provided by the CI/CD runner image, along with their language and kind`,
+		RunE:   workflow.List,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	workflowCacheCmd := &cobra.Command{
+		Use:    "cache",
+		Short:  "Manage workflow caches",
+		Long:   `Manage caches used by workflows, such as CI results and Go caches mounted into the runner`,
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: setLogLevel,
+	}
+
+	workflowCachePruneCmd := &cobra.Command{
+		Use:    "prune",
+		Short:  "Prune CI results cache",
+		Long:   `Remove cached CI results, so that next runs execute all checks`,
+		RunE:   workflow.CachePrune,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	workflowCacheInfoCmd := &cobra.Command{
+		Use:    "info",
+		Short:  "Show workflow caches",
+		Long:   `Show location and size of Go caches mounted into the runner, and of CI results cache`,
+		RunE:   workflow.CacheInfo,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	workflowCacheClearCmd := &cobra.Command{
+		Use:    "clear",
+		Short:  "Clear Go cache volumes",
+		Long:   `Remove Go cache volumes managed by kemutil. Host Go caches are left untouched, use ` + "`go clean -cache -modcache`" + ` to clear them`,
+		RunE:   workflow.CacheClear,
+		Args:   cobra.NoArgs,
+		PreRun: setLogLevel,
+	}
+
+	workflowImageCmd := &cobra.Command{
+		Use:    "image",
+		Short:  "Manage the runner image",
+		Long:   `Save and load the CI/CD runner image, for offline use or air-gapped machines`,
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: setLogLevel,
+	}
+
+	workflowImageSaveCmd := &cobra.Command{
+		Use:    "save <file>",
+		Short:  "Save the runner image to a tarball",
+		Long:   `Save the CI/CD runner image to a tarball, gzip compressed if file name ends with .gz`,
+		RunE:   workflow.ImageSave,
+		Args:   cobra.ExactArgs(1),
+		PreRun: setLogLevel,
+	}
+
+	workflowImageLoadCmd := &cobra.Command{
+		Use:    "load <file>",
+		Short:  "Load the runner image from a tarball",
+		Long:   `Load the CI/CD runner image from a tarball saved with ` + "`workflow image save`",
+		RunE:   workflow.ImageLoad,
+		Args:   cobra.ExactArgs(1),
+		PreRun: setLogLevel,
+	}
+
+	workflowRunCmd := &cobra.Command{
+		Use:   "run <workflow>",
+		Short: "Run a GitHub Actions workflow",
+		Long: `Run a GitHub Actions workflow locally, using the CI/CD runner
+
+	Workflow is either a path, or a file name in ` + workflow.GitHubWorkflowsDir + `, with or without extension.
+	Only ` + "`run`" + ` steps are executed, checkout steps are skipped as the repository is mounted.`,
+		RunE:   workflow.Run,
+		Args:   cobra.ExactArgs(1),
+		PreRun: setLogLevel,
+	}
+
 	rootCmd.AddCommand(workflowCmd)
 	workflowCmd.PersistentFlags().
 		BoolVar(&workflow.Hot, "hot", false, "Enable hot reload mode")
@@ -42,7 +126,64 @@ func init() {
 		BoolVar(&workflow.RunnerDebug, "runner-debug", false, "Enable debug mode for the CI/CD runner")
 	workflowCmd.PersistentFlags().
 		BoolVar(&workflow.ExportNetrc, "netrc", false, "Export netrc")
+	workflowCmd.PersistentFlags().
+		StringVar(&workflow.GoCache, "go-cache", workflow.GoCacheModeVolume, "Go caches mounted into the runner, one of \"volume\" (volumes managed by kemutil) or \"host\" (host GOMODCACHE and GOCACHE), both running the runner as the host user, or \"none\"")
 	workflowCmd.AddCommand(workflowCiCmd)
+	workflowCiCmd.PersistentFlags().
+		StringVar(&workflow.Repos, "repos", "", "Run in each repository matching given glob, or listed in given manifest file, one path or glob per line")
+	workflowCiCmd.PersistentFlags().
+		IntVar(&workflow.Concurrency, "concurrency", 4, "Number of repositories processed at once, used with --repos")
+	workflowCiCmd.PersistentFlags().
+		StringSliceVar(&workflow.Only, "only", nil, "Run only checks matching given names, languages or kinds, see \"workflow list\"")
+	workflowCiCmd.PersistentFlags().
+		StringSliceVar(&workflow.Skip, "skip", nil, "Skip checks matching given names, languages or kinds, see \"workflow list\"")
+	workflowCiCmd.PersistentFlags().
+		BoolVar(&workflow.Changed, "changed", false, "Check only files changed relative to the base branch, including staged and unstaged changes. Requires a runner image supporting it")
+	workflowCiCmd.PersistentFlags().
+		StringVar(&workflow.Base, "base", "main", "Branch changes are computed against, used with --changed")
+	workflowCiCmd.PersistentFlags().
+		BoolVar(&workflow.NoCache, "no-cache", false, "Run all checks, even those whose inputs did not change since their last success")
+	workflowCiCmd.PersistentFlags().
+		StringVar(&workflow.ReportDir, "report-dir", "", "Directory checks write SARIF, JUnit and JSON reports to, in a subdirectory per run, summarized after the run. Disables results cache, and cannot be used with --watch. Requires a runner image supporting it")
+	workflowCiCmd.PersistentFlags().
+		BoolVar(&workflow.Watch, "watch", false, "Keep running, re-running checks whose inputs changed on files changes, on changed files only with a runner image supporting it. Files ignored by git are not watched")
+	workflowCiCmd.PersistentFlags().
+		DurationVar(&workflow.Debounce, "debounce", 300*time.Millisecond, "How long files must stay unchanged before checks re-run, used with --watch")
+	workflowCiCmd.PersistentFlags().
+		StringVar(&workflow.SarifOutput, "sarif", "", "Write all reports merged as a single SARIF file to given path, used with --report-dir")
 	workflowCmd.AddCommand(workflowCustomCmd)
+	workflowCustomCmd.PersistentFlags().
+		StringVar(&workflow.Repos, "repos", "", "Run in each repository matching given glob, or listed in given manifest file, one path or glob per line")
+	workflowCustomCmd.PersistentFlags().
+		IntVar(&workflow.Concurrency, "concurrency", 4, "Number of repositories processed at once, used with --repos")
+	workflowCmd.AddCommand(workflowCacheCmd)
+	workflowCacheCmd.AddCommand(workflowCachePruneCmd)
+	workflowCacheCmd.AddCommand(workflowCacheInfoCmd)
+	workflowCacheCmd.AddCommand(workflowCacheClearCmd)
+	workflowCachePruneCmd.PersistentFlags().
+		DurationVar(&workflow.MaxAge, "max-age", 0, "Prune only entries older than given duration, all entries if unset")
+	workflowCmd.AddCommand(workflowListCmd)
+	workflowCmd.AddCommand(workflowImageCmd)
+	workflowImageCmd.AddCommand(workflowImageSaveCmd)
+	workflowImageCmd.AddCommand(workflowImageLoadCmd)
+	workflowCmd.AddCommand(workflowRunCmd)
+	workflowRunCmd.PersistentFlags().
+		StringVar(&workflow.Job, "job", "", "Run only this job, along with the jobs it needs")
 	workflowCmd.PersistentFlags().BoolVar(&workflow.Fix, "fix", false, "Enable fix mode")
+	workflowCmd.PersistentFlags().
+		StringArrayVar(&workflow.Env, "env", nil, "Set an environment variable in the runner container, as KEY=VALUE, or KEY to forward the host value")
+	workflowCmd.PersistentFlags().
+		StringArrayVar(&workflow.Volumes, "volume", nil, "Mount an extra volume in the runner container, using docker syntax")
+	workflowCmd.PersistentFlags().
+		StringVar(&workflow.Network, "network", "", "Docker network mode of the runner container")
+	workflowCmd.PersistentFlags().
+		StringVar(&workflow.CPUs, "cpus", "", "Number of CPUs the runner container may use")
+	workflowCmd.PersistentFlags().
+		StringVar(&workflow.Memory, "memory", "", "Memory limit of the runner container, such as 4g")
+	workflowCmd.PersistentFlags().
+		StringVar(&workflow.Registry, "registry", "", "Registry mirror to pull the runner image from, as host[/prefix]")
+	workflowCmd.PersistentFlags().
+		BoolVar(&workflow.Scope, "scope", false, "Mount only the current directory in the runner container, instead of the whole repository")
+	workflowCmd.PersistentFlags().
+		BoolVar(&workflow.AsUser, "as-user", false, "Run the runner container as the host user, instead of giving files modified in fix mode back to the host user after the run, as is always the case with Go caches mounted")
 }
@@ -11,6 +11,7 @@ require (
 	github.com/kemadev/infrastructure-components/deploy/kubernetes/40-control-plane v0.0.0-20251011115744-747c40d2e824
 	github.com/kemadev/infrastructure-components/deploy/pki/30-root-ca v0.0.0-20251011115744-747c40d2e824
 	github.com/spf13/cobra v1.10.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
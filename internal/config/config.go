@@ -0,0 +1,231 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/kemadev/kemutil/internal/gitrepo"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// UserConfigSubPath is the path of the user configuration file, relative to the user config directory.
+	UserConfigSubPath = "kemutil/config.yaml"
+	// RepoConfigFileName is the name of the repository configuration file, placed at the repository root.
+	RepoConfigFileName = ".kemutil.yaml"
+)
+
+// Config is kemutil configuration.
+type Config struct {
+	// Credentials configures how git credentials are retrieved.
+	Credentials Credentials `yaml:"credentials"`
+	// Hooks configures git hooks installed by kemutil.
+	Hooks Hooks `yaml:"hooks"`
+	// Workflow configures workflow runner containers.
+	Workflow Workflow `yaml:"workflow"`
+	// Dev configures the local development environment.
+	Dev Dev `yaml:"dev"`
+}
+
+// Credentials configures the credential providers chain. It is only read from the user
+// configuration.
+type Credentials struct {
+	// Providers is the ordered list of providers to try for hosts without a specific entry.
+	Providers []string `yaml:"providers"`
+	// Hosts maps a git host to the ordered list of providers to try for it.
+	Hosts map[string][]string `yaml:"hosts"`
+	// EnvVar is the name of the environment variable read by the env provider.
+	EnvVar string `yaml:"envVar"`
+}
+
+// Hooks configures git hooks.
+type Hooks struct {
+	// Disabled lists hooks that are neither installed nor run.
+	Disabled []string `yaml:"disabled"`
+	// CommitTypes lists allowed conventional commit types, replacing the default ones.
+	CommitTypes []string `yaml:"commitTypes"`
+}
+
+// Workflow configures workflow runner containers. Command line flags take precedence.
+type Workflow struct {
+	// Env lists host environment variables forwarded to runner containers, "*" wildcards allowed.
+	Env []string `yaml:"env"`
+	// EnvValues maps environment variables set in runner containers to their literal value.
+	EnvValues map[string]string `yaml:"envValues"`
+	// Volumes lists extra docker volume specifications. Relative host paths are resolved against
+	// the repository root. Env, EnvValues, Volumes and Network are only read from the user
+	// configuration, as are Flags not allowed in repositories.
+	Volumes []string `yaml:"volumes"`
+	// Network is the docker network mode of runner containers.
+	Network string `yaml:"network"`
+	// CPUs is the number of CPUs runner containers may use.
+	CPUs string `yaml:"cpus"`
+	// Memory is the memory limit of runner containers, such as 4g.
+	Memory string `yaml:"memory"`
+	// Registry is a registry mirror the runner image is pulled from, as host[/prefix]. It is only
+	// read from the user configuration.
+	Registry string `yaml:"registry"`
+	// Flags maps workflow command flags to their default value, such as fix: true.
+	Flags map[string]string `yaml:"flags"`
+}
+
+// Dev configures the local development environment.
+type Dev struct {
+	// ComposeSearchPath lists directories compose files are looked for in, relative to the
+	// repository root.
+	ComposeSearchPath []string `yaml:"composeSearchPath"`
+	// Profiles lists compose profiles enabled by default.
+	Profiles []string `yaml:"profiles"`
+}
+
+// repoAllowedFlags lists workflow flags whose default can be set by the repository
+// configuration. Others, such as those exposing host secrets and files to runner containers, or
+// selecting the runner image, are only read from the user configuration.
+//
+//nolint:gochecknoglobals // Used as a const
+var repoAllowedFlags = []string{
+	"base",
+	"changed",
+	"concurrency",
+	"cpus",
+	"debounce",
+	"fix",
+	"hot",
+	"job",
+	"max-age",
+	"memory",
+	"no-cache",
+	"only",
+	"runner-debug",
+	"scope",
+	"skip",
+	"watch",
+}
+
+// Load reads the user configuration file, then the repository configuration file.
+// Values set in the repository configuration take precedence, except for credentials, workflow
+// env, envValues, volumes, network and registry, and flags not listed in [repoAllowedFlags], which
+// are only read from the user configuration, as repositories are not trusted with host secrets and
+// files, nor with choosing the image they are exposed to. Missing files are ignored.
+func Load() (Config, error) {
+	conf := Config{}
+
+	userConfigDir, err := os.UserConfigDir()
+	if err == nil {
+		err := loadFile(filepath.Join(userConfigDir, UserConfigSubPath), &conf)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	repoRoot, err := gitrepo.Root()
+	if err != nil {
+		return conf, nil
+	}
+
+	// Maps are cloned, as decoding the repository configuration adds to them
+	userCredentials := conf.Credentials
+	userCredentials.Hosts = maps.Clone(userCredentials.Hosts)
+	user := conf.Workflow
+	user.EnvValues = maps.Clone(user.EnvValues)
+	user.Flags = maps.Clone(user.Flags)
+
+	path := filepath.Join(repoRoot, RepoConfigFileName)
+
+	// Decoded on its own too, to tell restricted settings it sets
+	repo := Config{}
+
+	err = loadFile(path, &repo)
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = loadFile(path, &conf)
+	if err != nil {
+		return Config{}, err
+	}
+
+	ignored := []string{}
+
+	if len(repo.Credentials.Providers) > 0 || len(repo.Credentials.Hosts) > 0 || repo.Credentials.EnvVar != "" {
+		ignored = append(ignored, "credentials")
+	}
+
+	if len(repo.Workflow.Env) > 0 || len(repo.Workflow.EnvValues) > 0 {
+		ignored = append(ignored, "workflow.env", "workflow.envValues")
+	}
+
+	if len(repo.Workflow.Volumes) > 0 {
+		ignored = append(ignored, "workflow.volumes")
+	}
+
+	if repo.Workflow.Network != "" {
+		ignored = append(ignored, "workflow.network")
+	}
+
+	if repo.Workflow.Registry != "" {
+		ignored = append(ignored, "workflow.registry")
+	}
+
+	conf.Credentials = userCredentials
+	conf.Workflow.Env = user.Env
+	conf.Workflow.EnvValues = user.EnvValues
+	conf.Workflow.Volumes = user.Volumes
+	conf.Workflow.Network = user.Network
+	conf.Workflow.Registry = user.Registry
+
+	for _, flag := range slices.Sorted(maps.Keys(repo.Workflow.Flags)) {
+		if slices.Contains(repoAllowedFlags, flag) {
+			continue
+		}
+
+		ignored = append(ignored, "workflow.flags."+flag)
+
+		delete(conf.Workflow.Flags, flag)
+
+		if value, ok := user.Flags[flag]; ok {
+			conf.Workflow.Flags[flag] = value
+		}
+	}
+
+	if len(ignored) > 0 {
+		slog.Warn(
+			"Ignoring repository configuration exposing host secrets or files, set it in the user configuration",
+			slog.String("path", path),
+			slog.Any("settings", ignored),
+		)
+	}
+
+	return conf, nil
+}
+
+// loadFile decodes the configuration file at path into conf, ignoring it if it does not exist.
+func loadFile(path string, conf *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			slog.Debug("Config file not found", slog.String("path", path))
+
+			return nil
+		}
+
+		return fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+
+	err = yaml.Unmarshal(content, conf)
+	if err != nil {
+		return fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	slog.Debug("Config file loaded", slog.String("path", path))
+
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeConfigs writes the user and repository configuration files, then moves to the repository.
+func writeConfigs(t *testing.T, user string, repo string) {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	userConfigDir, err := os.UserConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+
+	for path, content := range map[string]string{
+		filepath.Join(userConfigDir, UserConfigSubPath): user,
+		filepath.Join(root, RepoConfigFileName):         repo,
+	} {
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = os.Mkdir(filepath.Join(root, ".git"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(root)
+}
+
+func TestLoadFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		user string
+		repo string
+		want map[string]string
+	}{
+		{
+			name: "allowed flags",
+			user: "workflow: {flags: {fix: 'false', only: lint}}",
+			repo: "workflow: {flags: {fix: 'true', skip: test}}",
+			want: map[string]string{"fix": "true", "only": "lint", "skip": "test"},
+		},
+		{
+			name: "restricted flags",
+			user: "workflow: {flags: {go-cache: none}}",
+			repo: "workflow: {flags: {netrc: 'true', go-cache: host, registry: evil.example.com, volume: '/:/host'}}",
+			want: map[string]string{"go-cache": "none"},
+		},
+		{
+			name: "restricted flags without user configuration",
+			user: "",
+			repo: "workflow: {flags: {netrc: 'true', env: GITHUB_TOKEN, fix: 'true'}}",
+			want: map[string]string{"fix": "true"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			writeConfigs(t, test.user, test.repo)
+
+			conf, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(conf.Workflow.Flags, test.want) {
+				t.Errorf("Load() flags = %v, want %v", conf.Workflow.Flags, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadCredentials(t *testing.T) {
+	writeConfigs(
+		t,
+		"credentials: {providers: [gh], hosts: {github.com: [gh]}}",
+		"credentials: {providers: [env], envVar: AWS_SECRET_ACCESS_KEY, hosts: {github.com: [env], gitlab.com: [env]}}",
+	)
+
+	conf, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := Credentials{Providers: []string{"gh"}, Hosts: map[string][]string{"github.com": {"gh"}}}
+	if !reflect.DeepEqual(conf.Credentials, want) {
+		t.Errorf("Load() credentials = %+v, want %+v", conf.Credentials, want)
+	}
+}
+
+func TestLoadRepoSettings(t *testing.T) {
+	writeConfigs(
+		t,
+		"hooks: {disabled: [pre-push]}\nworkflow: {env: [HOME], cpus: '2', registry: mirror.example.com}",
+		"hooks: {commitTypes: [feat]}\nworkflow: {env: ['*'], envValues: {A: b}, volumes: ['~:/home'], network: host, cpus: '4', registry: evil.example.com}",
+	)
+
+	conf, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	wantHooks := Hooks{Disabled: []string{"pre-push"}, CommitTypes: []string{"feat"}}
+	if !reflect.DeepEqual(conf.Hooks, wantHooks) {
+		t.Errorf("Load() hooks = %+v, want %+v", conf.Hooks, wantHooks)
+	}
+
+	wantWorkflow := Workflow{Env: []string{"HOME"}, CPUs: "4", Registry: "mirror.example.com"}
+	if !reflect.DeepEqual(conf.Workflow, wantWorkflow) {
+		t.Errorf("Load() workflow = %+v, want %+v", conf.Workflow, wantWorkflow)
+	}
+}
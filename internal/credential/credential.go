@@ -0,0 +1,175 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package credential
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/kemadev/go-framework/pkg/git"
+	"github.com/kemadev/kemutil/internal/config"
+	"github.com/kemadev/kemutil/internal/redact"
+)
+
+var (
+	ErrRepoURLInvalid      = errors.New("repository URL is invalid")
+	ErrNoCredential        = errors.New("no credential found")
+	ErrProviderUnknown     = errors.New("unknown credential provider")
+	ErrProviderUnavailable = errors.New("credential provider is unavailable")
+)
+
+const (
+	ProviderEnv           = "env"
+	ProviderGitCredential = "git-credential"
+	ProviderNetrc         = "netrc"
+	ProviderKeyring       = "keyring"
+	ProviderGh            = "gh"
+)
+
+// DefaultProviders is the providers chain used when none is configured.
+//
+//nolint:gochecknoglobals // Used as a const
+var DefaultProviders = []string{
+	ProviderEnv,
+	ProviderGitCredential,
+	ProviderNetrc,
+	ProviderKeyring,
+	ProviderGh,
+}
+
+// Provider retrieves a token for a given git host.
+type Provider interface {
+	// Name returns the provider name, as used in configuration.
+	Name() string
+	// Token returns the token for host, or an error wrapping [ErrNoCredential] if it has none.
+	Token(host string) (string, error)
+}
+
+// NewProvider returns the provider registered under name.
+func NewProvider(name string, conf config.Credentials) (Provider, error) {
+	switch name {
+	case ProviderEnv:
+		return envProvider{varName: conf.EnvVar}, nil
+	case ProviderGitCredential:
+		return gitCredentialProvider{}, nil
+	case ProviderNetrc:
+		return netrcProvider{}, nil
+	case ProviderKeyring:
+		return keyringProvider{}, nil
+	case ProviderGh:
+		return ghProvider{}, nil
+	default:
+		return nil, fmt.Errorf("%q: %w", name, ErrProviderUnknown)
+	}
+}
+
+// Chain returns the providers to use for host, according to conf.
+func Chain(host string, conf config.Credentials) ([]Provider, error) {
+	names, ok := conf.Hosts[host]
+	if !ok {
+		names = conf.Providers
+	}
+
+	if len(names) == 0 {
+		names = DefaultProviders
+	}
+
+	providers := make([]Provider, 0, len(names))
+
+	for _, name := range names {
+		provider, err := NewProvider(name, conf)
+		if err != nil {
+			return nil, fmt.Errorf("error creating credential provider for host %s: %w", host, err)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+// Token returns the token for host, trying each provider of its chain in order.
+func Token(host string) (string, error) {
+	conf, err := config.Load()
+	if err != nil {
+		return "", fmt.Errorf("error loading config: %w", err)
+	}
+
+	providers, err := Chain(host, conf.Credentials)
+	if err != nil {
+		return "", err
+	}
+
+	errs := []error{}
+
+	for _, provider := range providers {
+		token, err := provider.Token(host)
+		if err == nil && token != "" {
+			redact.Register(token)
+
+			slog.Debug(
+				"Credential found",
+				slog.String("host", host),
+				slog.String("provider", provider.Name()),
+			)
+
+			return token, nil
+		}
+
+		if err == nil {
+			err = ErrNoCredential
+		}
+
+		slog.Debug(
+			"Credential provider did not return a token",
+			slog.String("host", host),
+			slog.String("provider", provider.Name()),
+			slog.String("error", err.Error()),
+		)
+
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+	}
+
+	return "", fmt.Errorf("error getting token for host %s: %w", host, errors.Join(append([]error{ErrNoCredential}, errs...)...))
+}
+
+// RemoteHost returns the host of the current git repository remote.
+func RemoteHost() (string, error) {
+	basePath, err := git.NewGitService().GetGitBasePath()
+	if err != nil {
+		return "", fmt.Errorf("error getting git repository: %w", err)
+	}
+
+	host, _, _ := strings.Cut(basePath, "/")
+	if host == "" {
+		return "", fmt.Errorf("error parsing git repository URL: %w", ErrRepoURLInvalid)
+	}
+
+	return host, nil
+}
+
+// Netrc returns the content of a netrc file granting access to host using token.
+func Netrc(host string, token string) string {
+	return `machine ` + host + `
+login git
+password ` + token + `
+`
+}
+
+// RemoteNetrc returns the content of a netrc file for the current git repository remote.
+func RemoteNetrc() (string, error) {
+	host, err := RemoteHost()
+	if err != nil {
+		return "", err
+	}
+
+	token, err := Token(host)
+	if err != nil {
+		return "", err
+	}
+
+	return Netrc(host, token), nil
+}
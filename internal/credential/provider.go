@@ -0,0 +1,205 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package credential
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultEnvVar is the environment variable read by the env provider when none is configured.
+const DefaultEnvVar = "KEMUTIL_GIT_TOKEN"
+
+// KeyringService is the value of the service attribute of keyring entries.
+// Entries can be created with `secret-tool store --label=kemutil service kemutil host <host>`.
+const KeyringService = "kemutil"
+
+// envProvider reads the token from an environment variable.
+type envProvider struct {
+	varName string
+}
+
+func (p envProvider) Name() string {
+	return ProviderEnv
+}
+
+func (p envProvider) Token(_ string) (string, error) {
+	varName := p.varName
+	if varName == "" {
+		varName = DefaultEnvVar
+	}
+
+	token, ok := os.LookupEnv(varName)
+	if !ok || token == "" {
+		return "", fmt.Errorf("environment variable %s is not set: %w", varName, ErrNoCredential)
+	}
+
+	return strings.TrimSpace(token), nil
+}
+
+// gitCredentialProvider asks configured git credential helpers, using `git credential fill`.
+type gitCredentialProvider struct{}
+
+func (p gitCredentialProvider) Name() string {
+	return ProviderGitCredential
+}
+
+func (p gitCredentialProvider) Token(host string) (string, error) {
+	binary, err := exec.LookPath("git")
+	if err != nil {
+		return "", fmt.Errorf("error finding git binary: %w", errors.Join(ErrProviderUnavailable, err))
+	}
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	com := exec.Command(binary, "credential", "fill")
+	com.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	// Never prompt, we only want stored credentials
+	com.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+
+	out, err := com.Output()
+	if err != nil {
+		return "", fmt.Errorf("error running git credential fill: %w", errors.Join(ErrNoCredential, err))
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		password, found := strings.CutPrefix(scanner.Text(), "password=")
+		if found && password != "" {
+			return password, nil
+		}
+	}
+
+	return "", fmt.Errorf("git credential fill returned no password: %w", ErrNoCredential)
+}
+
+// netrcProvider reads the token from the user's netrc file.
+type netrcProvider struct{}
+
+func (p netrcProvider) Name() string {
+	return ProviderNetrc
+}
+
+func (p netrcProvider) Token(host string) (string, error) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error getting home directory: %w", errors.Join(ErrProviderUnavailable, err))
+		}
+
+		path = filepath.Join(home, ".netrc")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading netrc file: %w", errors.Join(ErrNoCredential, err))
+	}
+
+	password := ""
+	defaultPassword := ""
+	// Either "machine", "default", or "" when outside of an entry
+	entry := ""
+	matching := false
+
+	fields := strings.Fields(string(content))
+	for pos := 0; pos < len(fields); pos++ {
+		switch fields[pos] {
+		case "machine":
+			entry = "machine"
+			matching = pos+1 < len(fields) && fields[pos+1] == host
+			pos++
+		case "default":
+			entry = "default"
+			matching = false
+		case "password":
+			if pos+1 >= len(fields) {
+				break
+			}
+
+			if entry == "machine" && matching && password == "" {
+				password = fields[pos+1]
+			}
+
+			if entry == "default" && defaultPassword == "" {
+				defaultPassword = fields[pos+1]
+			}
+
+			pos++
+		case "login", "account":
+			pos++
+		case "macdef":
+			// Macros end at the first empty line, which fields splitting cannot represent, skip the entry
+			entry = ""
+			matching = false
+		}
+	}
+
+	if password == "" {
+		password = defaultPassword
+	}
+
+	if password == "" {
+		return "", fmt.Errorf("no netrc entry for %s: %w", host, ErrNoCredential)
+	}
+
+	return password, nil
+}
+
+// keyringProvider reads the token from the OS keyring through the Secret Service D-Bus API, using `secret-tool`.
+type keyringProvider struct{}
+
+func (p keyringProvider) Name() string {
+	return ProviderKeyring
+}
+
+func (p keyringProvider) Token(host string) (string, error) {
+	binary, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return "", fmt.Errorf("error finding secret-tool binary: %w", errors.Join(ErrProviderUnavailable, err))
+	}
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	com := exec.Command(binary, "lookup", "service", KeyringService, "host", host)
+
+	out, err := com.Output()
+	if err != nil {
+		return "", fmt.Errorf("error looking up keyring: %w", errors.Join(ErrNoCredential, err))
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("no keyring entry for %s: %w", host, ErrNoCredential)
+	}
+
+	return token, nil
+}
+
+// ghProvider reads the token from the GitHub CLI.
+type ghProvider struct{}
+
+func (p ghProvider) Name() string {
+	return ProviderGh
+}
+
+func (p ghProvider) Token(host string) (string, error) {
+	binary, err := exec.LookPath("gh")
+	if err != nil {
+		return "", fmt.Errorf("error finding gh binary: %w", errors.Join(ErrProviderUnavailable, err))
+	}
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	com := exec.Command(binary, "auth", "token", "--hostname", host)
+
+	out, err := com.Output()
+	if err != nil {
+		return "", fmt.Errorf("error getting gh token: %w", errors.Join(ErrNoCredential, err))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,273 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package gitrepo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	g "github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+var (
+	ErrBaseNotFound      = errors.New("base revision not found")
+	ErrMergeBaseNotFound = errors.New("merge base not found")
+)
+
+// Open opens the git repository containing path, supporting worktrees.
+func Open(path string) (*g.Repository, error) {
+	repo, err := g.PlainOpenWithOptions(path, &g.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error opening git repository: %w", err)
+	}
+
+	return repo, nil
+}
+
+// ChangedFiles returns the files of the repository at root changed since the merge base of
+// HEAD and base, including staged, unstaged, and untracked changes. Paths are relative to
+// root, and deleted files are omitted.
+func ChangedFiles(root string, base string) ([]string, error) {
+	repo, err := Open(root)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]struct{}{}
+
+	committed, err := committedChanges(repo, base)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range committed {
+		files[file] = struct{}{}
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("error getting worktree: %w", err)
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("error getting worktree status: %w", err)
+	}
+
+	for file, fileStatus := range status {
+		if fileStatus.Staging == g.Unmodified && fileStatus.Worktree == g.Unmodified {
+			continue
+		}
+
+		files[file] = struct{}{}
+	}
+
+	changed := make([]string, 0, len(files))
+
+	for file := range files {
+		_, err := os.Stat(filepath.Join(root, file))
+		if err != nil {
+			continue
+		}
+
+		changed = append(changed, filepath.FromSlash(file))
+	}
+
+	slices.Sort(changed)
+
+	return changed, nil
+}
+
+// StagedFiles returns the files of the repository at root staged for commit, as listed by
+// `git diff --cached`. Paths are relative to root, and deleted files are omitted.
+func StagedFiles(root string) ([]string, error) {
+	repo, err := Open(root)
+	if err != nil {
+		return nil, err
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("error getting worktree: %w", err)
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("error getting worktree status: %w", err)
+	}
+
+	staged := []string{}
+
+	for file, fileStatus := range status {
+		switch fileStatus.Staging {
+		case g.Unmodified, g.Untracked, g.Deleted:
+			continue
+		default:
+			staged = append(staged, filepath.FromSlash(file))
+		}
+	}
+
+	slices.Sort(staged)
+
+	return staged, nil
+}
+
+// TrackedFiles returns the files of the repository at root that are either tracked, or
+// untracked but not ignored. Paths are relative to root, and deleted files are omitted.
+func TrackedFiles(root string) ([]string, error) {
+	repo, err := Open(root)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("error reading index: %w", err)
+	}
+
+	files := map[string]struct{}{}
+
+	for _, entry := range idx.Entries {
+		files[entry.Name] = struct{}{}
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("error getting worktree: %w", err)
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("error getting worktree status: %w", err)
+	}
+
+	for file, fileStatus := range status {
+		if fileStatus.Worktree == g.Untracked {
+			files[file] = struct{}{}
+		}
+	}
+
+	tracked := make([]string, 0, len(files))
+
+	for file := range files {
+		info, err := os.Stat(filepath.Join(root, file))
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		tracked = append(tracked, filepath.FromSlash(file))
+	}
+
+	slices.Sort(tracked)
+
+	return tracked, nil
+}
+
+// DirtyFiles returns the files of the repository at root with staged, unstaged, or untracked
+// changes. Paths are relative to root, and deleted files are included.
+func DirtyFiles(root string) ([]string, error) {
+	repo, err := Open(root)
+	if err != nil {
+		return nil, err
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("error getting worktree: %w", err)
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("error getting worktree status: %w", err)
+	}
+
+	dirty := []string{}
+
+	for file, fileStatus := range status {
+		if fileStatus.Staging == g.Unmodified && fileStatus.Worktree == g.Unmodified {
+			continue
+		}
+
+		dirty = append(dirty, filepath.FromSlash(file))
+	}
+
+	slices.Sort(dirty)
+
+	return dirty, nil
+}
+
+// committedChanges returns the files changed between the merge base of HEAD and base, and HEAD.
+func committedChanges(repo *g.Repository, base string) ([]string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("error getting HEAD: %w", err)
+	}
+
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("error getting HEAD commit: %w", err)
+	}
+
+	var baseHash *plumbing.Hash
+
+	for _, revision := range []string{base, "refs/remotes/origin/" + base} {
+		hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+		if err == nil {
+			baseHash = hash
+
+			break
+		}
+	}
+
+	if baseHash == nil {
+		return nil, fmt.Errorf("%q: %w", base, ErrBaseNotFound)
+	}
+
+	baseCommit, err := repo.CommitObject(*baseHash)
+	if err != nil {
+		return nil, fmt.Errorf("error getting base commit: %w", err)
+	}
+
+	mergeBases, err := headCommit.MergeBase(baseCommit)
+	if err != nil {
+		return nil, fmt.Errorf("error computing merge base: %w", err)
+	}
+
+	if len(mergeBases) == 0 {
+		return nil, fmt.Errorf("between HEAD and %q: %w", base, ErrMergeBaseNotFound)
+	}
+
+	mergeBaseTree, err := mergeBases[0].Tree()
+	if err != nil {
+		return nil, fmt.Errorf("error getting merge base tree: %w", err)
+	}
+
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("error getting HEAD tree: %w", err)
+	}
+
+	changes, err := object.DiffTree(mergeBaseTree, headTree)
+	if err != nil {
+		return nil, fmt.Errorf("error computing diff: %w", err)
+	}
+
+	files := make([]string, 0, len(changes))
+
+	for _, change := range changes {
+		if change.To.Name != "" {
+			files = append(files, change.To.Name)
+		}
+	}
+
+	return files, nil
+}
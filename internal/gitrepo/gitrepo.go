@@ -0,0 +1,45 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package gitrepo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var ErrNotInGitRepo = errors.New("not in a git repository")
+
+// Root returns the root directory of the git repository containing the current working directory.
+func Root() (string, error) {
+	workdir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("error getting current working directory: %w", err)
+	}
+
+	return RootFromPath(workdir)
+}
+
+// RootFromPath returns the root directory of the git repository containing path.
+func RootFromPath(path string) (string, error) {
+	repoRoot, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("error getting absolute path: %w", err)
+	}
+
+	for {
+		// .git is a directory for regular repositories, and a file for worktrees and submodules
+		if _, err := os.Stat(filepath.Join(repoRoot, ".git")); err == nil {
+			return repoRoot, nil
+		}
+
+		parent := filepath.Dir(repoRoot)
+		if parent == repoRoot {
+			return "", ErrNotInGitRepo
+		}
+
+		repoRoot = parent
+	}
+}
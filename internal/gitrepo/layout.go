@@ -0,0 +1,130 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package gitrepo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Layout describes where a repository lives on disk, as needed to mount it in a container.
+type Layout struct {
+	// Root is the root of the repository, as returned by [RootFromPath], that of the submodule
+	// itself for submodules.
+	Root string
+	// MountRoot is the top-level working tree to mount for git to work, that of the superproject
+	// for submodules, whose git directories live in the superproject one.
+	MountRoot string
+	// GitDirs are absolute paths of git directories outside of MountRoot the working tree refers
+	// to, such as the main repository git directory of a worktree.
+	GitDirs []string
+}
+
+// LayoutFromPath returns the layout of the repository containing path.
+func LayoutFromPath(path string) (Layout, error) {
+	repoRoot, err := RootFromPath(path)
+	if err != nil {
+		return Layout{}, err
+	}
+
+	root := repoRoot
+	gitDirs := []string{}
+
+	for {
+		info, err := os.Stat(filepath.Join(root, ".git"))
+		if err != nil {
+			return Layout{}, fmt.Errorf("error reading .git: %w", err)
+		}
+
+		if info.IsDir() {
+			break
+		}
+
+		gitDir, err := readGitFile(root)
+		if err != nil {
+			return Layout{}, err
+		}
+
+		// Submodules git directories live in the superproject one, under modules/
+		super, err := RootFromPath(filepath.Dir(root))
+		if err == nil && strings.Contains(filepath.ToSlash(gitDir), "/modules/") {
+			root = super
+
+			continue
+		}
+
+		// Worktrees git directories live in the main repository one, which holds objects and refs
+		commonDir, err := commonGitDir(gitDir)
+		if err != nil {
+			return Layout{}, err
+		}
+
+		if !within(root, commonDir) {
+			gitDirs = append(gitDirs, commonDir)
+		}
+
+		break
+	}
+
+	// Git directories of a superproject worktree hold the ones of its submodules
+	gitDirs = slices.DeleteFunc(gitDirs, func(dir string) bool {
+		return within(root, dir)
+	})
+
+	return Layout{
+		Root:      repoRoot,
+		MountRoot: root,
+		GitDirs:   gitDirs,
+	}, nil
+}
+
+// readGitFile returns the absolute git directory a .git file in root points to.
+func readGitFile(root string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(root, ".git"))
+	if err != nil {
+		return "", fmt.Errorf("error reading .git file: %w", err)
+	}
+
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("invalid .git file in %s: %w", root, ErrNotInGitRepo)
+	}
+
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(root, gitDir)
+	}
+
+	return filepath.Clean(gitDir), nil
+}
+
+// commonGitDir returns the git directory holding objects and refs shared with gitDir, which is
+// gitDir itself unless it is the git directory of a worktree.
+func commonGitDir(gitDir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if os.IsNotExist(err) {
+		return gitDir, nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("error reading commondir: %w", err)
+	}
+
+	commonDir := strings.TrimSpace(string(content))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+
+	return filepath.Clean(commonDir), nil
+}
+
+// within reports whether path is dir or one of its descendants.
+func within(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+// Package pollwatch detects files changes by polling their state, which works alike on all
+// platforms and file systems, including bind mounts and network ones.
+package pollwatch
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"time"
+)
+
+// State is the state of a file, as seen by a [Poller].
+type State struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// StateOf returns the state of the file described by info.
+func StateOf(info fs.FileInfo) State {
+	return State{ModTime: info.ModTime(), Size: info.Size()}
+}
+
+// SnapshotFunc returns the state of watched files, keyed by path.
+type SnapshotFunc func() (map[string]State, error)
+
+// Poller reports changes of watched files once they settled, as editors and tools often write in
+// several steps.
+type Poller struct {
+	snapshot   SnapshotFunc
+	debounce   time.Duration
+	states     map[string]State
+	pending    map[string]struct{}
+	lastChange time.Time
+}
+
+// New returns a poller of files listed by snapshot, reporting changes once files stayed unchanged
+// for debounce. Files state is recorded right away.
+func New(snapshot SnapshotFunc, debounce time.Duration) (*Poller, error) {
+	p := &Poller{
+		snapshot: snapshot,
+		debounce: debounce,
+		pending:  map[string]struct{}{},
+	}
+
+	err := p.Reset()
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Poll compares files to their previous state, and returns, sorted, files added, modified or
+// removed since the last reported changes, once they settled. It returns nil otherwise.
+func (p *Poller) Poll() ([]string, error) {
+	current, err := p.snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("error listing files: %w", err)
+	}
+
+	changed := Diff(p.states, current)
+	p.states = current
+
+	if len(changed) > 0 {
+		for _, file := range changed {
+			p.pending[file] = struct{}{}
+		}
+
+		p.lastChange = time.Now()
+
+		return nil, nil
+	}
+
+	if len(p.pending) == 0 || time.Since(p.lastChange) < p.debounce {
+		return nil, nil
+	}
+
+	files := slices.Sorted(maps.Keys(p.pending))
+	clear(p.pending)
+
+	return files, nil
+}
+
+// Reset records files state again, dropping unreported changes, so that changes made meanwhile,
+// such as by the caller itself, are not reported.
+func (p *Poller) Reset() error {
+	states, err := p.snapshot()
+	if err != nil {
+		return fmt.Errorf("error listing files: %w", err)
+	}
+
+	p.states = states
+
+	clear(p.pending)
+
+	return nil
+}
+
+// Diff returns files added, modified, or removed between before and after.
+func Diff(before map[string]State, after map[string]State) []string {
+	changed := []string{}
+
+	for file, state := range after {
+		if previous, ok := before[file]; !ok || !previous.ModTime.Equal(state.ModTime) || previous.Size != state.Size {
+			changed = append(changed, file)
+		}
+	}
+
+	for file := range before {
+		if _, ok := after[file]; !ok {
+			changed = append(changed, file)
+		}
+	}
+
+	return changed
+}
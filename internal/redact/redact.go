@@ -0,0 +1,211 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package redact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces secrets in redacted content.
+const Placeholder = "[REDACTED]"
+
+//nolint:gochecknoglobals // Secrets registry is shared by all loggers
+var (
+	mu       sync.RWMutex
+	secrets  = map[string]struct{}{}
+	replacer = strings.NewReplacer()
+)
+
+// minLineLength is the minimum length of lines of multi-line secrets registered on their own.
+const minLineLength = 16
+
+// Register registers secret so that it gets redacted from logs. Multi-line secrets, such as
+// private keys, also get each of their opaque lines registered, that is lines of at least
+// [minLineLength] characters without whitespace, so that PEM boundaries and structural lines of
+// documents, such as "apiVersion: v1", are not redacted everywhere.
+func Register(secret string) {
+	candidates := []string{strings.TrimSpace(secret)}
+
+	if strings.Contains(candidates[0], "\n") {
+		for line := range strings.Lines(candidates[0]) {
+			line = strings.TrimSpace(line)
+			if len(line) < minLineLength || strings.HasPrefix(line, "-----") || strings.ContainsAny(line, " \t") {
+				continue
+			}
+
+			candidates = append(candidates, line)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+
+		secrets[candidate] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(secrets))
+	for s := range secrets {
+		sorted = append(sorted, s)
+	}
+
+	// Replace longest secrets first, so that a secret containing another one is fully redacted
+	slices.SortFunc(sorted, func(a, b string) int {
+		return len(b) - len(a)
+	})
+
+	oldnew := make([]string, 0, 2*len(sorted))
+	for _, s := range sorted {
+		oldnew = append(oldnew, s, Placeholder)
+	}
+
+	replacer = strings.NewReplacer(oldnew...)
+}
+
+// String returns str with all registered secrets redacted.
+func String(str string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return replacer.Replace(str)
+}
+
+// Value returns val with all registered secrets redacted, descending into groups and
+// common container types. Other values are redacted based on their formatted representation.
+func Value(val slog.Value) slog.Value {
+	val = val.Resolve()
+
+	switch val.Kind() {
+	case slog.KindString:
+		return slog.StringValue(String(val.String()))
+	case slog.KindGroup:
+		attrs := val.Group()
+		redacted := make([]slog.Attr, len(attrs))
+
+		for pos, attr := range attrs {
+			redacted[pos] = Attr(attr)
+		}
+
+		return slog.GroupValue(redacted...)
+	case slog.KindAny:
+		return slog.AnyValue(anyValue(val.Any()))
+	default:
+		return val
+	}
+}
+
+func anyValue(val any) any {
+	switch typed := val.(type) {
+	case nil:
+		return nil
+	case string:
+		return String(typed)
+	case []string:
+		redacted := make([]string, len(typed))
+		for pos, s := range typed {
+			redacted[pos] = String(s)
+		}
+
+		return redacted
+	case []any:
+		redacted := make([]any, len(typed))
+		for pos, v := range typed {
+			redacted[pos] = anyValue(v)
+		}
+
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(typed))
+		for k, v := range typed {
+			redacted[String(k)] = String(v)
+		}
+
+		return redacted
+	case map[string]any:
+		redacted := make(map[string]any, len(typed))
+		for k, v := range typed {
+			redacted[String(k)] = anyValue(v)
+		}
+
+		return redacted
+	case slog.Value:
+		return Value(typed)
+	}
+
+	formatted := fmt.Sprintf("%+v", val)
+
+	redacted := String(formatted)
+	if redacted == formatted {
+		return val
+	}
+
+	return redacted
+}
+
+// Attr returns attr with all registered secrets redacted from its key and value.
+func Attr(attr slog.Attr) slog.Attr {
+	return slog.Attr{
+		Key:   String(attr.Key),
+		Value: Value(attr.Value),
+	}
+}
+
+// Handler is a [slog.Handler] redacting registered secrets from records before passing
+// them to the wrapped handler.
+type Handler struct {
+	handler slog.Handler
+}
+
+// NewHandler returns a [Handler] wrapping handler.
+func NewHandler(handler slog.Handler) *Handler {
+	return &Handler{handler: handler}
+}
+
+// Enabled reports whether the wrapped handler handles records at level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle redacts the record message and attributes, then passes it to the wrapped handler.
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, String(record.Message), record.PC)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(Attr(attr))
+
+		return true
+	})
+
+	err := h.handler.Handle(ctx, redacted)
+	if err != nil {
+		return fmt.Errorf("error handling redacted record: %w", err)
+	}
+
+	return nil
+}
+
+// WithAttrs returns a handler with attrs redacted and added to the wrapped handler.
+// Only secrets registered at call time are redacted from attrs.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for pos, attr := range attrs {
+		redacted[pos] = Attr(attr)
+	}
+
+	return &Handler{handler: h.handler.WithAttrs(redacted)}
+}
+
+// WithGroup returns a handler with the group name added to the wrapped handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{handler: h.handler.WithGroup(String(name))}
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/config"
+	"github.com/kemadev/kemutil/internal/gitrepo"
+)
+
+var ErrComposeFileNotFound = errors.New("compose file not found")
+
+var (
+	// DefaultComposeSearchPath is the list of directories compose files are looked for in,
+	// relative to the repository root.
+	//nolint:gochecknoglobals // Used as a const
+	DefaultComposeSearchPath = []string{"tool/dev", "."}
+	//nolint:gochecknoglobals // Used as a const
+	composeFileNames = []string{"docker-compose.yaml", "docker-compose.yml", "compose.yaml", "compose.yml"}
+)
+
+var (
+	// Files is a flag to set compose files, layered over discovered ones.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Files []string
+	// Profiles is a flag to set enabled compose profiles, replacing configured ones.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Profiles []string
+)
+
+// DefaultProfiles are the compose profiles enabled when neither flags nor configuration set them.
+//
+//nolint:gochecknoglobals // Used as a const
+var DefaultProfiles = []string{"dev"}
+
+// projectRoot returns the repository root, or the current directory outside of repositories.
+func projectRoot() (string, error) {
+	repoRoot, err := gitrepo.Root()
+	if err == nil {
+		return repoRoot, nil
+	}
+
+	if !errors.Is(err, gitrepo.ErrNotInGitRepo) {
+		return "", fmt.Errorf("error finding repository root: %w", err)
+	}
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("error getting current working directory: %w", err)
+	}
+
+	return workdir, nil
+}
+
+// composeFiles returns the compose files to use, in layering order: the first compose file found
+// in the search path, along with its override files (<name>.override.<ext>) and personal,
+// uncommitted ones (<name>.override.local.<ext>), then files set with [Files]. Discovered files
+// are optional when [Files] is set.
+func composeFiles(conf config.Dev) ([]string, error) {
+	files, err := discoverComposeFiles(conf)
+	if err != nil && (len(Files) == 0 || !errors.Is(err, ErrComposeFileNotFound)) {
+		return nil, err
+	}
+
+	for _, file := range Files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, fmt.Errorf("error getting absolute path: %w", err)
+		}
+
+		if !slices.Contains(files, abs) {
+			files = append(files, abs)
+		}
+	}
+
+	return files, nil
+}
+
+// discoverComposeFiles returns the first compose file found in the search path, along with its
+// override files.
+func discoverComposeFiles(conf config.Dev) ([]string, error) {
+	root, err := projectRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	searchPath := DefaultComposeSearchPath
+	if len(conf.ComposeSearchPath) > 0 {
+		searchPath = conf.ComposeSearchPath
+	}
+
+	for _, dir := range searchPath {
+		for _, name := range composeFileNames {
+			base := filepath.Join(root, dir, name)
+
+			_, err := os.Stat(base)
+			if err != nil {
+				continue
+			}
+
+			files := []string{base}
+
+			ext := filepath.Ext(name)
+			stem := strings.TrimSuffix(name, ext)
+
+			for _, override := range []string{stem + ".override" + ext, stem + ".override.local" + ext} {
+				path := filepath.Join(root, dir, override)
+
+				_, err := os.Stat(path)
+				if err == nil {
+					files = append(files, path)
+				}
+			}
+
+			slog.Debug("Found compose files", slog.Any("files", files))
+
+			return files, nil
+		}
+	}
+
+	return nil, fmt.Errorf("in %s, searched %s: %w", root, strings.Join(searchPath, ", "), ErrComposeFileNotFound)
+}
+
+// profiles returns the compose profiles to enable: [Profiles] if set, configured ones otherwise,
+// and [DefaultProfiles] as a last resort. [Debug] is a shortcut for the debug profile, which
+// replaces default ones.
+func profiles(conf config.Dev) []string {
+	if len(Profiles) > 0 {
+		enabled := slices.Clone(Profiles)
+		if Debug && !slices.Contains(enabled, "debug") {
+			enabled = append(enabled, "debug")
+		}
+
+		return enabled
+	}
+
+	if Debug {
+		return []string{"debug"}
+	}
+
+	if len(conf.Profiles) > 0 {
+		return conf.Profiles
+	}
+
+	return DefaultProfiles
+}
+
+// composeArgs returns docker compose base arguments, selecting profiles and compose files.
+func composeArgs() ([]string, error) {
+	conf, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+
+	return composeArgsFor(conf.Dev, profiles(conf.Dev))
+}
+
+// composeArgsFor returns docker compose base arguments, selecting given profiles and compose files.
+func composeArgsFor(conf config.Dev, enabled []string) ([]string, error) {
+	files, err := composeFiles(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"compose"}
+
+	for _, profile := range enabled {
+		args = append(args, "--profile", profile)
+	}
+
+	// Ports remapped by dev up --auto-ports come last, so that they win
+	portsOverride, err := existingPortsOverride()
+	if err != nil {
+		return nil, err
+	}
+
+	if portsOverride != "" {
+		files = append(files, portsOverride)
+	}
+
+	for _, file := range files {
+		args = append(args, "--file", file)
+	}
+
+	return args, nil
+}
@@ -0,0 +1,449 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// databaseURLEnvVarKey is the environment variable of the application holding the database
+	// connection URL.
+	databaseURLEnvVarKey = "KEMA_CLIENT_DATABASE_CONNECTION_URL"
+	// migrationsDir is the directory, relative to the repository root, of versioned SQL migrations,
+	// named <version>_<name>.sql.
+	migrationsDir = "tool/dev/db/migrations"
+	// fixturesDir is the directory, relative to the repository root, of SQL fixtures, applied in
+	// lexical order.
+	fixturesDir = "tool/dev/db/fixtures"
+	// migrationsTable is the table applied migrations versions are recorded in.
+	migrationsTable = "kemutil_schema_migrations"
+	// fixturesTable is the table applied fixtures are recorded in, along with their checksum.
+	fixturesTable = "kemutil_applied_fixtures"
+	// passwordEnvVarKey is the environment variable psql reads the password from, keeping it out
+	// of command arguments.
+	passwordEnvVarKey = "PGPASSWORD"
+	// defaultPostgresPort is the port of database connection URLs without one.
+	defaultPostgresPort = "5432"
+)
+
+var (
+	ErrDatabaseNotConfigured = errors.New("database connection URL not configured")
+	ErrMigrationNameInvalid  = errors.New("invalid migration file name")
+)
+
+// singleTransactionArgs are psql arguments running all of its input in a single transaction, as
+// psql only does for input read with --command or --file.
+//
+//nolint:gochecknoglobals // Used as a const
+var singleTransactionArgs = []string{"--single-transaction", "--file=-"}
+
+// migrationNameRegexp matches migration file names, capturing their version and name.
+//
+//nolint:gochecknoglobals // Used as a const
+var migrationNameRegexp = regexp.MustCompile(`^([0-9]+)_(.+)\.sql$`)
+
+// database is the dev database, as reached from within its service container.
+type database struct {
+	service string
+	// url is the connection URL, without password
+	url      string
+	password string
+}
+
+// migration is a versioned SQL migration.
+type migration struct {
+	version string
+	name    string
+	path    string
+}
+
+// DBMigrate applies pending migrations to the dev database.
+func DBMigrate(_ *cobra.Command, _ []string) error {
+	db, err := devDatabase(EnvService)
+	if err != nil {
+		return err
+	}
+
+	return db.migrate()
+}
+
+// DBSeed applies pending migrations, then fixtures, to the dev database.
+func DBSeed(_ *cobra.Command, _ []string) error {
+	db, err := devDatabase(EnvService)
+	if err != nil {
+		return err
+	}
+
+	return db.seed()
+}
+
+// seedDatabase applies pending migrations, then fixtures, to the dev database, if the application
+// uses one.
+func seedDatabase() error {
+	db, err := devDatabase(DefaultEnvService)
+	if err != nil {
+		if errors.Is(err, ErrDatabaseNotConfigured) {
+			slog.Debug("No dev database, skipping migrations and fixtures")
+
+			return nil
+		}
+
+		return err
+	}
+
+	return db.seed()
+}
+
+// DBShell opens an interactive psql session on the dev database, within its service container.
+func DBShell(_ *cobra.Command, _ []string) error {
+	db, err := devDatabase(EnvService)
+	if err != nil {
+		return err
+	}
+
+	// Inherited by docker compose, which passes it to psql
+	err = os.Setenv(passwordEnvVarKey, db.password)
+	if err != nil {
+		return fmt.Errorf("error setting %s: %w", passwordEnvVarKey, err)
+	}
+
+	subArgs := []string{"exec", "--env", passwordEnvVarKey}
+
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		subArgs = append(subArgs, "--no-TTY")
+	}
+
+	return execCompose(append(subArgs, db.service, "psql", db.url)...)
+}
+
+// devDatabase returns the dev database, found from the connection URL of the application
+// environment of service name.
+func devDatabase(name string) (database, error) {
+	conf, err := config.Load()
+	if err != nil {
+		return database{}, fmt.Errorf("error loading config: %w", err)
+	}
+
+	project, err := loadFullProject(conf.Dev)
+	if err != nil {
+		return database{}, err
+	}
+
+	service, ok := project.Services[name]
+	if !ok {
+		return database{}, fmt.Errorf("%s: %w", name, ErrServiceNotFound)
+	}
+
+	rawURL, ok := service.env(databaseURLEnvVarKey)
+	if !ok || rawURL == "" {
+		return database{}, fmt.Errorf("%s not set in %s: %w", databaseURLEnvVarKey, name, ErrDatabaseNotConfigured)
+	}
+
+	dbURL, err := url.Parse(rawURL)
+	if err != nil {
+		return database{}, fmt.Errorf("error parsing %s: %w", databaseURLEnvVarKey, err)
+	}
+
+	for serviceName, dbService := range project.Services {
+		if !slices.Contains(serviceHosts(project, serviceName, dbService), dbURL.Hostname()) {
+			continue
+		}
+
+		// psql runs within the service container, where the database listens on localhost
+		dbURL.Host = "localhost:" + cmp.Or(dbURL.Port(), defaultPostgresPort)
+
+		password, _ := dbURL.User.Password()
+		if dbURL.User != nil {
+			dbURL.User = url.User(dbURL.User.Username())
+		}
+
+		slog.Debug("Found dev database", slog.String("service", serviceName))
+
+		return database{service: serviceName, url: dbURL.String(), password: password}, nil
+	}
+
+	return database{}, fmt.Errorf("no service for database host %s: %w", dbURL.Hostname(), ErrServiceNotFound)
+}
+
+// psqlArgs returns compose arguments running psql in the database service container with args.
+func (db database) psqlArgs(args ...string) []string {
+	return slices.Concat(
+		[]string{"exec", "--no-TTY", "--env", passwordEnvVarKey, db.service, "psql", "--no-psqlrc", "--quiet", "-v", "ON_ERROR_STOP=1"},
+		args,
+		[]string{db.url},
+	)
+}
+
+// psql runs psql in the database service container with args, reading input from stdin.
+func (db database) psql(stdin io.Reader, args ...string) ([]byte, error) {
+	com, err := composeCommand(db.psqlArgs(args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Passed through the environment, as arguments are logged
+	com.Env = append(os.Environ(), passwordEnvVarKey+"="+db.password)
+	com.Stdin = stdin
+
+	out, err := com.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running psql: %w", err)
+	}
+
+	return out, nil
+}
+
+// appliedVersions returns versions of migrations applied to the database, creating the table
+// recording them if needed.
+func (db database) appliedVersions() (map[string]struct{}, error) {
+	query := "CREATE TABLE IF NOT EXISTS " + migrationsTable + " (version TEXT PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT now());\n" +
+		"SELECT version FROM " + migrationsTable + ";\n"
+
+	out, err := db.psql(strings.NewReader(query), "--tuples-only", "--no-align")
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[string]struct{}{}
+
+	for _, version := range strings.Fields(string(out)) {
+		applied[version] = struct{}{}
+	}
+
+	return applied, nil
+}
+
+// migrate applies pending migrations in version order, each in its own transaction along with
+// the record of its version.
+func (db database) migrate() error {
+	migrations, err := readMigrations()
+	if err != nil {
+		return err
+	}
+
+	if len(migrations) == 0 {
+		slog.Info("No migrations found", slog.String("dir", migrationsDir))
+
+		return nil
+	}
+
+	applied, err := db.appliedVersions()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+
+	for _, mig := range migrations {
+		if _, ok := applied[mig.version]; ok {
+			continue
+		}
+
+		slog.Info("Applying migration", slog.String("version", mig.version), slog.String("name", mig.name))
+
+		content, err := os.ReadFile(mig.path)
+		if err != nil {
+			return fmt.Errorf("error reading migration: %w", err)
+		}
+
+		record := fmt.Sprintf(
+			"\n;\nINSERT INTO %s (version, name) VALUES ('%s', '%s');\n",
+			migrationsTable,
+			mig.version,
+			strings.ReplaceAll(mig.name, "'", "''"),
+		)
+
+		_, err = db.psql(io.MultiReader(bytes.NewReader(content), strings.NewReader(record)), singleTransactionArgs...)
+		if err != nil {
+			return fmt.Errorf("error applying migration %s: %w", filepath.Base(mig.path), err)
+		}
+
+		count++
+	}
+
+	slog.Info("Database is up to date", slog.Int("applied", count), slog.Int("migrations", len(migrations)))
+
+	return nil
+}
+
+// seed applies pending migrations, then fixtures.
+func (db database) seed() error {
+	err := db.migrate()
+	if err != nil {
+		return err
+	}
+
+	return db.applyFixtures()
+}
+
+// appliedFixtures returns checksums of fixtures applied to the database, keyed by file name,
+// creating the table recording them if needed.
+func (db database) appliedFixtures() (map[string]string, error) {
+	query := "CREATE TABLE IF NOT EXISTS " + fixturesTable + " (name TEXT PRIMARY KEY, checksum TEXT NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT now());\n" +
+		"SELECT name, checksum FROM " + fixturesTable + ";\n"
+
+	out, err := db.psql(strings.NewReader(query), "--tuples-only", "--no-align")
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[string]string{}
+
+	for line := range strings.Lines(string(out)) {
+		sep := strings.LastIndex(line, "|")
+		if sep < 0 {
+			continue
+		}
+
+		applied[line[:sep]] = strings.TrimSpace(line[sep+1:])
+	}
+
+	return applied, nil
+}
+
+// applyFixtures applies SQL fixtures in lexical order, each in its own transaction along with the
+// record of its checksum. Fixtures already applied are skipped, and changed ones are applied again.
+func (db database) applyFixtures() error {
+	root, err := projectRoot()
+	if err != nil {
+		return err
+	}
+
+	fixtures, err := filepath.Glob(filepath.Join(root, fixturesDir, "*.sql"))
+	if err != nil {
+		return fmt.Errorf("error listing fixtures: %w", err)
+	}
+
+	if len(fixtures) == 0 {
+		slog.Info("No fixtures found", slog.String("dir", fixturesDir))
+
+		return nil
+	}
+
+	applied, err := db.appliedFixtures()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+
+	for _, fixture := range fixtures {
+		name := filepath.Base(fixture)
+
+		content, err := os.ReadFile(fixture)
+		if err != nil {
+			return fmt.Errorf("error reading fixture: %w", err)
+		}
+
+		sum := sha256.Sum256(content)
+		checksum := hex.EncodeToString(sum[:])
+
+		previous, ok := applied[name]
+
+		switch {
+		case ok && previous == checksum:
+			slog.Debug("Fixture already applied", slog.String("fixture", name))
+
+			continue
+		case ok:
+			slog.Info("Fixture changed, applying it again", slog.String("fixture", name))
+		default:
+			slog.Info("Applying fixture", slog.String("fixture", name))
+		}
+
+		record := fmt.Sprintf(
+			"\n;\nINSERT INTO %s (name, checksum) VALUES ('%s', '%s') "+
+				"ON CONFLICT (name) DO UPDATE SET checksum = EXCLUDED.checksum, applied_at = now();\n",
+			fixturesTable,
+			strings.ReplaceAll(name, "'", "''"),
+			checksum,
+		)
+
+		_, err = db.psql(io.MultiReader(bytes.NewReader(content), strings.NewReader(record)), singleTransactionArgs...)
+		if err != nil {
+			return fmt.Errorf("error applying fixture %s: %w", name, err)
+		}
+
+		count++
+	}
+
+	slog.Info("Fixtures are up to date", slog.Int("applied", count), slog.Int("fixtures", len(fixtures)))
+
+	return nil
+}
+
+// readMigrations returns migrations of the migrations directory, sorted by version.
+func readMigrations() ([]migration, error) {
+	root, err := projectRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(root, migrationsDir)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error listing migrations: %w", err)
+	}
+
+	migrations := []migration{}
+	versions := map[string]string{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		match := migrationNameRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%s, expected <version>_<name>.sql: %w", entry.Name(), ErrMigrationNameInvalid)
+		}
+
+		// Leading zeros are not significant, so that 0001 and 1 are the same version
+		version := strings.TrimLeft(match[1], "0")
+		if version == "" {
+			version = "0"
+		}
+
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("%s and %s share version %s: %w", other, entry.Name(), version, ErrMigrationNameInvalid)
+		}
+
+		versions[version] = entry.Name()
+
+		migrations = append(migrations, migration{
+			version: version,
+			name:    match[2],
+			path:    filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	slices.SortFunc(migrations, func(a, b migration) int {
+		return cmp.Or(cmp.Compare(len(a.version), len(b.version)), strings.Compare(a.version, b.version))
+	})
+
+	return migrations, nil
+}
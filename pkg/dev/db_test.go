@@ -0,0 +1,120 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "no migrations directory",
+			files: nil,
+			want:  []string{},
+		},
+		{
+			name:  "numeric order",
+			files: []string{"10_c.sql", "2_b.sql", "1_a.sql"},
+			want:  []string{"1 a", "2 b", "10 c"},
+		},
+		{
+			name:  "leading zeros",
+			files: []string{"0002_b.sql", "0001_a.sql", "0010_c.sql"},
+			want:  []string{"1 a", "2 b", "10 c"},
+		},
+		{
+			name:  "version zero",
+			files: []string{"1_a.sql", "000_init.sql"},
+			want:  []string{"0 init", "1 a"},
+		},
+		{
+			name:  "other files ignored",
+			files: []string{"1_a.sql", "README.md", "sub/2_b.sql"},
+			want:  []string{"1 a"},
+		},
+		{
+			name:    "invalid name",
+			files:   []string{"1_a.sql", "init.sql"},
+			wantErr: ErrMigrationNameInvalid,
+		},
+		{
+			name:    "duplicate version",
+			files:   []string{"1_a.sql", "01_b.sql"},
+			wantErr: ErrMigrationNameInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+
+			err := os.Mkdir(filepath.Join(root, ".git"), 0o755)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, file := range test.files {
+				path := filepath.Join(root, migrationsDir, file)
+
+				err := os.MkdirAll(filepath.Dir(path), 0o755)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				err = os.WriteFile(path, []byte("SELECT 1;\n"), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			t.Chdir(root)
+
+			migrations, err := readMigrations()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("readMigrations() error = %v, want %v", err, test.wantErr)
+			}
+
+			if test.wantErr != nil {
+				return
+			}
+
+			got := []string{}
+			for _, mig := range migrations {
+				got = append(got, mig.version+" "+mig.name)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("readMigrations() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPsqlArgs(t *testing.T) {
+	t.Parallel()
+
+	db := database{service: "postgres", url: "postgres://app@localhost:5432/app"}
+
+	got := db.psqlArgs(singleTransactionArgs...)
+	want := []string{
+		"exec", "--no-TTY", "--env", passwordEnvVarKey, "postgres",
+		"psql", "--no-psqlrc", "--quiet", "-v", "ON_ERROR_STOP=1",
+		"--single-transaction", "--file=-",
+		"postgres://app@localhost:5432/app",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("psqlArgs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,596 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// EditorVSCode generates a VS Code launch configuration.
+	EditorVSCode = "vscode"
+	// EditorGoLand generates a GoLand run configuration.
+	EditorGoLand = "goland"
+	// EditorNvimDap generates a nvim-dap configuration.
+	EditorNvimDap = "nvim-dap"
+	// debugConfigName is the name of generated debug configurations, replaced on regeneration. It is
+	// prefixed so that it does not collide with configurations of users.
+	debugConfigName = "kemutil: attach app-debug"
+	// debugProfile is the compose profile of debug services.
+	debugProfile = "debug"
+	// delvePort is the port delve listens on in debug services.
+	delvePort = 50000
+	// debugSourceDir is the path sources are built from in debug services.
+	debugSourceDir = "/src"
+	// goLandConfigFile is the GoLand run configuration file, relative to the repository root.
+	goLandConfigFile = ".run/kemutil-debug.run.xml"
+	// nvimBlockStart and nvimBlockEnd delimit the generated block of the nvim configuration.
+	nvimBlockStart = "-- BEGIN kemutil debug-config, generated by kemutil dev debug-config"
+	nvimBlockEnd   = "-- END kemutil debug-config"
+)
+
+var (
+	ErrEditorInvalid        = errors.New("invalid editor")
+	ErrDebuggerPortNotFound = errors.New("debugger port not found")
+	ErrLaunchConfigInvalid  = errors.New("invalid launch configuration")
+)
+
+// Editor is a flag to set the editor to generate debug configuration for.
+//
+//nolint:gochecknoglobals // Cobra flags are global
+var Editor string
+
+// debugTarget is the debugger of the debug service, as reached from the host.
+type debugTarget struct {
+	host string
+	port int
+	root string
+}
+
+// DebugConfig generates or updates the debug configuration of [Editor], so that it connects to the
+// debugger of the debug service, with sources mapped to the repository root. Other configurations
+// are left untouched.
+func DebugConfig(_ *cobra.Command, _ []string) error {
+	write, ok := map[string]func(debugTarget) (string, error){
+		EditorVSCode:  writeVSCodeConfig,
+		EditorGoLand:  writeGoLandConfig,
+		EditorNvimDap: writeNvimDapConfig,
+	}[Editor]
+	if !ok {
+		return fmt.Errorf("%q, expected %s, %s or %s: %w", Editor, EditorVSCode, EditorGoLand, EditorNvimDap, ErrEditorInvalid)
+	}
+
+	target, err := findDebugTarget()
+	if err != nil {
+		return err
+	}
+
+	path, err := write(target)
+	if err != nil {
+		return err
+	}
+
+	slog.Info(
+		"Wrote debug configuration",
+		slog.String("editor", Editor),
+		slog.String("path", path),
+		slog.String("address", target.host+":"+strconv.Itoa(target.port)),
+	)
+
+	return nil
+}
+
+// findDebugTarget returns the published address of the delve port of debug profile services.
+func findDebugTarget() (debugTarget, error) {
+	conf, err := config.Load()
+	if err != nil {
+		return debugTarget{}, fmt.Errorf("error loading config: %w", err)
+	}
+
+	project, err := loadFullProject(conf.Dev)
+	if err != nil {
+		return debugTarget{}, err
+	}
+
+	root, err := projectRoot()
+	if err != nil {
+		return debugTarget{}, err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(project.Services)) {
+		service := project.Services[name]
+		if !slices.Contains(service.Profiles, debugProfile) {
+			continue
+		}
+
+		for _, port := range service.Ports {
+			if port.Target != delvePort || port.Published == "" {
+				continue
+			}
+
+			published, err := strconv.Atoi(port.Published)
+			if err != nil {
+				return debugTarget{}, fmt.Errorf("error parsing published port of %s: %w", name, err)
+			}
+
+			slog.Debug("Found debugger port", slog.String("service", name), slog.Int("port", published))
+
+			return debugTarget{host: localHost(port.HostIP), port: published, root: root}, nil
+		}
+	}
+
+	return debugTarget{}, fmt.Errorf(
+		"no service of profile %s publishes port %d: %w",
+		debugProfile,
+		delvePort,
+		ErrDebuggerPortNotFound,
+	)
+}
+
+// launchConfiguration is a VS Code launch configuration, attaching to the headless delve server of
+// the debug service, which serves DAP clients as well.
+type launchConfiguration struct {
+	Name                 string              `json:"name"`
+	Type                 string              `json:"type"`
+	DebugAdapter         string              `json:"debugAdapter,omitempty"`
+	Request              string              `json:"request"`
+	Mode                 string              `json:"mode"`
+	HideSystemGoroutines bool                `json:"hideSystemGoroutines"`
+	SubstitutePath       []map[string]string `json:"substitutePath"`
+	Host                 string              `json:"host"`
+	Port                 int                 `json:"port"`
+}
+
+// newLaunchConfiguration returns the launch configuration of target, with sourceRoot mapped to the
+// sources directory of the debug service.
+func newLaunchConfiguration(target debugTarget, sourceRoot string) launchConfiguration {
+	return launchConfiguration{
+		Name:                 debugConfigName,
+		Type:                 "go",
+		DebugAdapter:         "dlv-dap",
+		Request:              "attach",
+		Mode:                 "remote",
+		HideSystemGoroutines: true,
+		SubstitutePath:       []map[string]string{{"from": sourceRoot, "to": debugSourceDir}},
+		Host:                 target.host,
+		Port:                 target.port,
+	}
+}
+
+// writeVSCodeConfig adds the launch configuration to .vscode/launch.json, replacing the one of the
+// same name, or one connecting to the same debugger. It is spliced in as text, so that the rest of
+// the file, comments and layout included, is kept as written.
+func writeVSCodeConfig(target debugTarget) (string, error) {
+	path := filepath.Join(target.root, ".vscode", "launch.json")
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("error reading launch configuration: %w", err)
+	}
+
+	if len(bytes.TrimSpace(content)) == 0 {
+		content = []byte("{\n\t\"version\": \"0.2.0\",\n\t\"configurations\": []\n}\n")
+	}
+
+	content, err = spliceLaunchConfiguration(content, newLaunchConfiguration(target, "${workspaceFolder}"))
+	if err != nil {
+		return "", err
+	}
+
+	return path, writeConfigFile(path, content)
+}
+
+// spliceLaunchConfiguration returns launch configuration content with launch replacing the
+// configurations of the same name, or else Go configurations connecting to the same debugger, as
+// they would do the same, or appended to configurations if there is none. Only the text of the
+// replaced configurations, or the inserted one, changes.
+func spliceLaunchConfiguration(content []byte, launch launchConfiguration) ([]byte, error) {
+	// Comments and trailing commas are blanked, so that offsets match content
+	blanked := stripJSONC(content)
+	if !json.Valid(blanked) {
+		return nil, fmt.Errorf("launch configuration is not valid JSON: %w", ErrLaunchConfigInvalid)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(blanked))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("error reading launch configuration: %w", err)
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected an object, got %v: %w", token, ErrLaunchConfigInvalid)
+	}
+
+	membersEnd := int(decoder.InputOffset())
+	hasMembers := false
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error reading launch configuration: %w", err)
+		}
+
+		if key, _ := token.(string); key == "configurations" {
+			return spliceConfigurations(content, decoder, launch)
+		}
+
+		err = decoder.Decode(&json.RawMessage{})
+		if err != nil {
+			return nil, fmt.Errorf("error reading launch configuration: %w", err)
+		}
+
+		membersEnd = int(decoder.InputOffset())
+		hasMembers = true
+	}
+
+	// Add an empty configurations array after the last member, and splice into it
+	member := "\n\t\"configurations\": []"
+	if hasMembers {
+		member = "," + member
+	} else {
+		member += "\n"
+	}
+
+	return spliceLaunchConfiguration(insertText(content, membersEnd, member), launch)
+}
+
+// spliceConfigurations splices launch into the configurations array of content, decoder being
+// positioned right before it.
+func spliceConfigurations(content []byte, decoder *json.Decoder, launch launchConfiguration) ([]byte, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("error reading launch configurations: %w", err)
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expected configurations to be an array, got %v: %w", token, ErrLaunchConfigInvalid)
+	}
+
+	open := int(decoder.InputOffset())
+	lastEnd := -1
+	named := []int{}
+	sameTarget := []int{}
+
+	for decoder.More() {
+		configuration := json.RawMessage{}
+
+		err := decoder.Decode(&configuration)
+		if err != nil {
+			return nil, fmt.Errorf("error reading launch configurations: %w", err)
+		}
+
+		lastEnd = int(decoder.InputOffset())
+
+		existing := struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+			Host string `json:"host"`
+			Port int    `json:"port"`
+		}{}
+
+		err = json.Unmarshal(configuration, &existing)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case existing.Name == launch.Name:
+			named = append(named, lastEnd-len(configuration), lastEnd)
+		case existing.Type == launch.Type && existing.Host == launch.Host && existing.Port == launch.Port:
+			sameTarget = append(sameTarget, lastEnd-len(configuration), lastEnd)
+		}
+	}
+
+	replaced := named
+	if len(replaced) == 0 {
+		replaced = sameTarget
+	}
+
+	_, err = decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("error reading launch configurations: %w", err)
+	}
+
+	closing := int(decoder.InputOffset()) - 1
+
+	// Replaced from the end, so that offsets of previous configurations still hold
+	for i := len(replaced) - 2; i >= 0; i -= 2 {
+		start, end := replaced[i], replaced[i+1]
+
+		text, err := launchConfigurationText(launch, lineIndent(content, start))
+		if err != nil {
+			return nil, err
+		}
+
+		content = slices.Concat(content[:start], text, content[end:])
+	}
+
+	switch {
+	case len(replaced) > 0:
+		return content, nil
+	case lastEnd >= 0:
+		indent := lineIndent(content, lastEnd-1)
+
+		text, err := launchConfigurationText(launch, indent)
+		if err != nil {
+			return nil, err
+		}
+
+		return insertText(content, lastEnd, ",\n"+indent+string(text)), nil
+	default:
+		indent := lineIndent(content, open)
+
+		text, err := launchConfigurationText(launch, indent+"\t")
+		if err != nil {
+			return nil, err
+		}
+
+		inserted := "\n" + indent + "\t" + string(text)
+		if !bytes.ContainsRune(content[open:closing], '\n') {
+			inserted += "\n" + indent
+		}
+
+		return insertText(content, open, inserted), nil
+	}
+}
+
+// launchConfigurationText returns launch as indented JSON, continuation lines being prefixed by
+// indent.
+func launchConfigurationText(launch launchConfiguration, indent string) ([]byte, error) {
+	out := bytes.Buffer{}
+
+	// Not HTML-escaped, as VS Code does not escape it either
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent(indent, "\t")
+
+	err := encoder.Encode(launch)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling launch configuration: %w", err)
+	}
+
+	return bytes.TrimSpace(out.Bytes()), nil
+}
+
+// lineIndent returns the leading whitespace of the line of content at offset.
+func lineIndent(content []byte, offset int) string {
+	line := content[bytes.LastIndexByte(content[:offset], '\n')+1 : offset]
+
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
+
+// insertText returns content with text inserted at offset.
+func insertText(content []byte, offset int, text string) []byte {
+	return slices.Concat(content[:offset], []byte(text), content[offset:])
+}
+
+// stripJSONC returns content with comments and trailing commas, as allowed in VS Code configuration
+// files, replaced by spaces. Line breaks are kept, so that offsets and lines match content.
+func stripJSONC(content []byte) []byte {
+	out := slices.Clone(content)
+	inString := false
+
+	blank := func(start int, end int) {
+		for i := start; i < end; i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	for i := 0; i < len(out); i++ {
+		char := out[i]
+
+		switch {
+		case inString:
+			if char == '\\' {
+				i++
+			} else if char == '"' {
+				inString = false
+			}
+		case char == '"':
+			inString = true
+		case char == '/' && i+1 < len(out) && out[i+1] == '/':
+			end := bytes.IndexByte(out[i:], '\n')
+			if end < 0 {
+				end = len(out) - i
+			}
+
+			blank(i, i+end)
+			i += end - 1
+		case char == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(out) - i
+			} else {
+				end += 4
+			}
+
+			blank(i, i+end)
+			i += end - 1
+		case char == '}' || char == ']':
+			previous := len(bytes.TrimRight(out[:i], " \t\r\n")) - 1
+			if previous >= 0 && out[previous] == ',' {
+				out[previous] = ' '
+			}
+		}
+	}
+
+	return out
+}
+
+// goLandComponent is a GoLand run configuration file.
+type goLandComponent struct {
+	XMLName       xml.Name            `xml:"component"`
+	Name          string              `xml:"name,attr"`
+	Configuration goLandConfiguration `xml:"configuration"`
+}
+
+// goLandConfiguration is a GoLand run configuration.
+type goLandConfiguration struct {
+	Default     bool           `xml:"default,attr"`
+	Name        string         `xml:"name,attr"`
+	Type        string         `xml:"type,attr"`
+	FactoryName string         `xml:"factoryName,attr"`
+	Options     []goLandOption `xml:"option"`
+	Method      goLandMethod   `xml:"method"`
+}
+
+// goLandOption is an option of a GoLand run configuration.
+type goLandOption struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// goLandMethod lists tasks run before a GoLand run configuration, none here.
+type goLandMethod struct {
+	V int `xml:"v,attr"`
+}
+
+// writeGoLandConfig writes a Go Remote run configuration, attaching to the headless delve server
+// of the debug service, to the .run directory GoLand reads shared run configurations from. The
+// file is kemutil's own, other configurations are left untouched. GoLand maps sources built in
+// the debug service to the project on its own.
+func writeGoLandConfig(target debugTarget) (string, error) {
+	path := filepath.Join(target.root, filepath.FromSlash(goLandConfigFile))
+
+	content, err := xml.MarshalIndent(goLandComponent{
+		Name: "ProjectRunConfigurationManager",
+		Configuration: goLandConfiguration{
+			Name:        debugConfigName,
+			Type:        "GoRemoteDebugConfigurationType",
+			FactoryName: "Go Remote",
+			Options: []goLandOption{
+				// Leave the debug service running when detaching, as other clients may use it
+				{Name: "disconnectOption", Value: "LEAVE"},
+				{Name: "host", Value: target.host},
+				{Name: "port", Value: strconv.Itoa(target.port)},
+			},
+			Method: goLandMethod{V: 2},
+		},
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshalling GoLand configuration: %w", err)
+	}
+
+	return path, writeConfigFile(path, append(content, '\n'))
+}
+
+// writeNvimDapConfig writes a nvim-dap adapter and configuration to .nvim.lua, loaded by Neovim
+// when exrc is set, within a delimited block replaced on regeneration. Content outside of the
+// block is left untouched.
+func writeNvimDapConfig(target debugTarget) (string, error) {
+	path := filepath.Join(target.root, ".nvim.lua")
+
+	launch := newLaunchConfiguration(target, target.root)
+	launch.Type = "kemutil_delve"
+	launch.DebugAdapter = ""
+
+	encoded, err := json.Marshal(launch)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling nvim-dap configuration: %w", err)
+	}
+
+	configuration := map[string]any{}
+
+	err = json.Unmarshal(encoded, &configuration)
+	if err != nil {
+		return "", fmt.Errorf("error converting nvim-dap configuration: %w", err)
+	}
+
+	lines := []string{
+		nvimBlockStart,
+		"local dap = require('dap')",
+		"dap.adapters.kemutil_delve = { type = 'server', host = " + luaValue(target.host) + ", port = " + strconv.Itoa(target.port) + " }",
+		"dap.configurations.go = vim.tbl_filter(function(configuration)",
+		"  return configuration.name ~= " + luaValue(debugConfigName),
+		"end, dap.configurations.go or {})",
+		"table.insert(dap.configurations.go, " + luaValue(configuration) + ")",
+		nvimBlockEnd,
+	}
+	block := strings.Join(lines, "\n") + "\n"
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("error reading nvim configuration: %w", err)
+	}
+
+	existing := string(content)
+
+	start := strings.Index(existing, nvimBlockStart)
+	end := strings.Index(existing, nvimBlockEnd)
+
+	switch {
+	case start >= 0 && end > start:
+		existing = existing[:start] + block + strings.TrimPrefix(existing[end+len(nvimBlockEnd):], "\n")
+	case existing != "" && !strings.HasSuffix(existing, "\n"):
+		existing += "\n\n" + block
+	case existing != "":
+		existing += "\n" + block
+	default:
+		existing = block
+	}
+
+	return path, writeConfigFile(path, []byte(existing))
+}
+
+// luaValue returns value, as decoded from JSON, as a Lua literal.
+func luaValue(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return strconv.Quote(typed)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(typed)
+	case bool:
+		return strconv.FormatBool(typed)
+	case []any:
+		items := []string{}
+		for _, item := range typed {
+			items = append(items, luaValue(item))
+		}
+
+		return "{ " + strings.Join(items, ", ") + " }"
+	case map[string]any:
+		fields := []string{}
+		for _, key := range slices.Sorted(maps.Keys(typed)) {
+			fields = append(fields, key+" = "+luaValue(typed[key]))
+		}
+
+		return "{ " + strings.Join(fields, ", ") + " }"
+	default:
+		return "nil"
+	}
+}
+
+// writeConfigFile writes content to path, creating its directory if needed.
+func writeConfigFile(path string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+
+	err = os.WriteFile(path, content, 0o644)
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "no comments",
+			content: `{"a": [1, 2]}`,
+			want:    `{"a": [1, 2]}`,
+		},
+		{
+			name:    "line comment",
+			content: "{\n\"a\": 1 // one\n}",
+			want:    "{\n\"a\": 1       \n}",
+		},
+		{
+			name:    "line comment at end of content",
+			content: "{}// end",
+			want:    "{}      ",
+		},
+		{
+			name:    "block comment spanning lines",
+			content: "{/* a\nb */\"a\": 1}",
+			want:    "{    \n    \"a\": 1}",
+		},
+		{
+			name:    "unterminated block comment",
+			content: "{} /* end",
+			want:    "{}       ",
+		},
+		{
+			name:    "comment markers in strings",
+			content: `{"url": "http://host/*path*/"}`,
+			want:    `{"url": "http://host/*path*/"}`,
+		},
+		{
+			name:    "escaped quotes in strings",
+			content: `{"a": "\"// not a comment", "b": "\\"} // comment`,
+			want:    `{"a": "\"// not a comment", "b": "\\"}           `,
+		},
+		{
+			name:    "trailing commas",
+			content: "{\"a\": [1, 2,],\n}",
+			want:    "{\"a\": [1, 2 ] \n}",
+		},
+		{
+			name:    "trailing comma before comment",
+			content: "[1, // one\n]",
+			want:    "[1        \n]",
+		},
+		{
+			name:    "comma in string kept",
+			content: `["a,"]`,
+			want:    `["a,"]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := string(stripJSONC([]byte(test.content)))
+			if got != test.want {
+				t.Errorf("stripJSONC() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSpliceLaunchConfiguration(t *testing.T) {
+	t.Parallel()
+
+	launch := launchConfiguration{Name: debugConfigName, Type: "go", Port: 1}
+	generated := func(indent string) string {
+		text, err := launchConfigurationText(launch, indent)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(text)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "empty configurations",
+			content: "{\n\t\"version\": \"0.2.0\",\n\t\"configurations\": []\n}\n",
+			want:    "{\n\t\"version\": \"0.2.0\",\n\t\"configurations\": [\n\t\t" + generated("\t\t") + "\n\t]\n}\n",
+		},
+		{
+			name:    "appended after user configurations",
+			content: "{\n  // mine\n  \"configurations\": [\n    {\"name\": \"Start debugging\"}, // kept\n  ],\n}\n",
+			want:    "{\n  // mine\n  \"configurations\": [\n    {\"name\": \"Start debugging\"},\n    " + generated("    ") + ", // kept\n  ],\n}\n",
+		},
+		{
+			name:    "replaced in place",
+			content: "{\"configurations\": [\n\t/* a */ {\"name\": \"kemutil: attach app-debug\"},\n\t{\"name\": \"other\"}\n]}",
+			want:    "{\"configurations\": [\n\t/* a */ " + generated("\t") + ",\n\t{\"name\": \"other\"}\n]}",
+		},
+		{
+			name:    "replaced configuration of the same debugger",
+			content: "{\"configurations\": [\n\t{\"name\": \"Start debugging\", \"type\": \"go\", \"port\": 1}\n]}",
+			want:    "{\"configurations\": [\n\t" + generated("\t") + "\n]}",
+		},
+		{
+			name:    "named configuration replaced over the same debugger",
+			content: "{\"configurations\": [\n\t{\"name\": \"Start debugging\", \"type\": \"go\", \"port\": 1},\n\t{\"name\": \"kemutil: attach app-debug\"}\n]}",
+			want:    "{\"configurations\": [\n\t{\"name\": \"Start debugging\", \"type\": \"go\", \"port\": 1},\n\t" + generated("\t") + "\n]}",
+		},
+		{
+			name:    "missing configurations",
+			content: "{\n\t\"version\": \"0.2.0\" // v\n}",
+			want:    "{\n\t\"version\": \"0.2.0\",\n\t\"configurations\": [\n\t\t" + generated("\t\t") + "\n\t] // v\n}",
+		},
+		{
+			name:    "invalid content",
+			content: "{\"configurations\": [",
+			wantErr: ErrLaunchConfigInvalid,
+		},
+		{
+			name:    "configurations not an array",
+			content: `{"configurations": {}}`,
+			wantErr: ErrLaunchConfigInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := spliceLaunchConfiguration([]byte(test.content), launch)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("spliceLaunchConfiguration() error = %v, want %v", err, test.wantErr)
+			}
+
+			if test.wantErr == nil && string(got) != test.want {
+				t.Errorf("spliceLaunchConfiguration() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -5,19 +5,22 @@ package dev
 
 import (
 	"errors"
-	"fmt"
 	"log/slog"
-	"os"
-	"os/exec"
-	"strings"
-	"syscall"
 
-	"github.com/kemadev/ci-cd/pkg/auth"
-	"github.com/kemadev/go-framework/pkg/git"
+	"github.com/kemadev/kemutil/internal/credential"
 	"github.com/spf13/cobra"
 )
 
-var ErrRepoURLInvalid = errors.New("repository URL is invalid")
+var ErrLiveDetached = errors.New("hot reload is not available in detached mode")
+
+// ErrRepoURLInvalid is returned when the repository remote URL cannot be parsed while exporting
+// netrc.
+//
+// Deprecated: netrc export moved to credential providers, this alias of the error they return is
+// kept for compatibility.
+//
+//nolint:gochecknoglobals // Kept for compatibility
+var ErrRepoURLInvalid = credential.ErrRepoURLInvalid
 
 var (
 	// Debug is a flag to enable debug profile
@@ -35,109 +38,41 @@ var (
 func StartLocal(_ *cobra.Command, _ []string) error {
 	slog.Info("Starting local development server")
 
-	binary, err := exec.LookPath("docker")
+	err := exportNetrc()
 	if err != nil {
-		return fmt.Errorf("docker binary not found: %w", err)
-	}
-
-	profile := "dev"
-	if Debug {
-		profile = "debug"
+		return err
 	}
 
-	baseArgs := []string{
-		"compose",
-		"--profile",
-		profile,
-		"--file",
-		"./tool/dev/docker-compose.yaml",
-		"up",
-		"--build",
+	err = checkPorts()
+	if err != nil {
+		return err
 	}
 
-	if ExportNetrc {
-		machine, err := git.NewGitService().GetGitBasePath()
-		if err != nil {
-			return fmt.Errorf("error getting git repository: %w", err)
-		}
-
-		machineParts := strings.Split(machine, "/")
-		if len(machineParts) < 1 {
-			return fmt.Errorf("error parsing git repository URL: %w", ErrRepoURLInvalid)
-		}
-
-		machine = machineParts[0]
-
-		ghBinary, err := exec.LookPath("gh")
-		if err != nil {
-			return fmt.Errorf("error finding gh binary: %w", err)
-		}
-
-		ghArgs := []string{
-			"auth",
-			"token",
-		}
-
-		// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
-		com := exec.Command(ghBinary, ghArgs...)
-
-		token, err := com.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("error getting git token command output: %w", err)
+	if Detach || Wait {
+		if Live {
+			return ErrLiveDetached
 		}
 
-		netrc := `machine ` + machine + `
-login git
-password ` + string(token) + `
-`
-		os.Setenv(auth.NetrcEnvVarKey, netrc)
+		return startDetached(Wait)
 	}
 
-	if Live {
-		baseArgs = append(baseArgs, "--watch")
-	}
+	subArgs := []string{"up", "--build"}
 
-	slog.Debug("Running command", slog.Any("binary", binary), slog.Any("baseArgs", baseArgs))
-
-	// nosemgrep: go.lang.security.audit.dangerous-syscall-exec.dangerous-syscall-exec // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
-	err = syscall.Exec(binary, append([]string{binary}, baseArgs...), os.Environ())
-	if err != nil {
-		return fmt.Errorf("error running workflow ci command: %w", err)
+	if Live {
+		subArgs = append(subArgs, "--watch")
 	}
 
-	return nil
+	return execCompose(subArgs...)
 }
 
 // StopLocal stops the live development server.
 func StopLocal(_ *cobra.Command, _ []string) error {
 	slog.Info("Shutting down local development server")
 
-	binary, err := exec.LookPath("docker")
-	if err != nil {
-		return fmt.Errorf("docker binary not found: %w", err)
-	}
-
-	profile := "dev"
-	if Debug {
-		profile = "debug"
-	}
-
-	baseArgs := []string{
-		"compose",
-		"--profile",
-		profile,
-		"--file",
-		"./tool/dev/docker-compose.yaml",
-		"down",
-	}
-
-	slog.Debug("Running command", slog.Any("binary", binary), slog.Any("baseArgs", baseArgs))
-
-	// nosemgrep: go.lang.security.audit.dangerous-syscall-exec.dangerous-syscall-exec // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
-	err = syscall.Exec(binary, append([]string{binary}, baseArgs...), os.Environ())
+	err := runCompose("down")
 	if err != nil {
-		return fmt.Errorf("error running workflow ci command: %w", err)
+		return err
 	}
 
-	return nil
+	return removePortsOverride()
 }
@@ -0,0 +1,270 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/kemadev/kemutil/internal/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// EnvFormatDotenv outputs environment as a .env file.
+	EnvFormatDotenv = "dotenv"
+	// EnvFormatShell outputs environment as shell exports.
+	EnvFormatShell = "shell"
+	// DefaultEnvService is the service application environment is taken from by default.
+	DefaultEnvService = "app-template"
+	// appEnvPrefix is the prefix of environment variables of the application.
+	appEnvPrefix = "KEMA_"
+	// appNameEnvVarKey is the environment variable holding the application name.
+	appNameEnvVarKey = "KEMA_RUNTIME_APP_NAME"
+)
+
+var (
+	ErrEnvFormatInvalid = errors.New("invalid environment format")
+	ErrServiceNotFound  = errors.New("service not found")
+)
+
+var (
+	// EnvService is a flag to set the service environment is taken from.
+	//nolint:gochecknoglobals // Cobra flags are global
+	EnvService string
+	// EnvFormat is a flag to set the environment output format, one of [EnvFormatDotenv] or
+	// [EnvFormatShell].
+	//nolint:gochecknoglobals // Cobra flags are global
+	EnvFormat string
+	// EnvOutput is a flag to write environment to a file instead of the standard output.
+	//nolint:gochecknoglobals // Cobra flags are global
+	EnvOutput string
+	// RunPackage is a flag to set the package run natively, defaulting to cmd/<app name>.
+	//nolint:gochecknoglobals // Cobra flags are global
+	RunPackage string
+)
+
+// Env outputs the application environment of the compose project, rewritten to be used on the
+// host.
+func Env(_ *cobra.Command, _ []string) error {
+	if EnvFormat != EnvFormatDotenv && EnvFormat != EnvFormatShell {
+		return fmt.Errorf("%q, expected %s or %s: %w", EnvFormat, EnvFormatDotenv, EnvFormatShell, ErrEnvFormatInvalid)
+	}
+
+	env, err := hostEnv(EnvService)
+	if err != nil {
+		return err
+	}
+
+	var writer io.Writer = os.Stdout
+
+	if EnvOutput != "" {
+		file, err := os.OpenFile(EnvOutput, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return fmt.Errorf("error opening environment file: %w", err)
+		}
+		defer file.Close()
+
+		writer = file
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		if EnvFormat == EnvFormatShell {
+			fmt.Fprintf(writer, "export %s=%s\n", key, shellQuote(env[key]))
+
+			continue
+		}
+
+		fmt.Fprintf(writer, "%s=%s\n", key, dotenvQuote(env[key]))
+	}
+
+	if EnvOutput != "" {
+		slog.Info("Wrote environment", slog.String("path", EnvOutput), slog.Int("variables", len(env)))
+	}
+
+	return nil
+}
+
+// Run runs the application natively on the host, with the compose project environment rewritten
+// to be used on the host. Arguments are passed to the application. With [Live], the application is
+// rebuilt and restarted on changes.
+func Run(_ *cobra.Command, args []string) error {
+	env, err := hostEnv(EnvService)
+	if err != nil {
+		return err
+	}
+
+	pkg, err := runPackage(env)
+	if err != nil {
+		return err
+	}
+
+	binary, err := exec.LookPath("go")
+	if err != nil {
+		return fmt.Errorf("go binary not found: %w", err)
+	}
+
+	if Live {
+		return runLive(binary, pkg, args, mergeEnv(os.Environ(), env))
+	}
+
+	baseArgs := append([]string{"run", pkg}, args...)
+
+	slog.Debug("Running command", slog.Any("binary", binary), slog.Any("baseArgs", baseArgs))
+
+	// nosemgrep: go.lang.security.audit.dangerous-syscall-exec.dangerous-syscall-exec // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	err = syscall.Exec(binary, append([]string{binary}, baseArgs...), mergeEnv(os.Environ(), env))
+	if err != nil {
+		return fmt.Errorf("error running go command: %w", err)
+	}
+
+	return nil
+}
+
+// hostEnv returns the application environment of service name, with addresses of other services
+// rewritten to the host and their published ports.
+func hostEnv(name string) (map[string]string, error) {
+	conf, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+
+	project, err := loadFullProject(conf.Dev)
+	if err != nil {
+		return nil, err
+	}
+
+	service, ok := project.Services[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrServiceNotFound)
+	}
+
+	addresses := hostAddresses(project)
+
+	// Longest addresses first, so that none is rewritten as part of another one
+	containerAddresses := slices.SortedFunc(maps.Keys(addresses), func(a, b string) int {
+		return cmp.Or(len(b)-len(a), strings.Compare(a, b))
+	})
+
+	env := map[string]string{}
+
+	for key := range service.Environment {
+		value, ok := service.env(key)
+		if !ok || !strings.HasPrefix(key, appEnvPrefix) {
+			continue
+		}
+
+		for _, address := range containerAddresses {
+			value = strings.ReplaceAll(value, address, addresses[address])
+		}
+
+		env[key] = value
+	}
+
+	slog.Debug("Rewrote environment", slog.Any("addresses", addresses))
+
+	return env, nil
+}
+
+// hostAddresses maps addresses services are reached at from other containers, by container or
+// service name, to the ones of their published ports on the host.
+func hostAddresses(project composeProject) map[string]string {
+	addresses := map[string]string{}
+
+	for name, service := range project.Services {
+		hosts := serviceHosts(project, name, service)
+
+		for _, port := range service.Ports {
+			if port.Published == "" {
+				continue
+			}
+
+			for _, host := range hosts {
+				containerAddress := net.JoinHostPort(host, strconv.Itoa(port.Target))
+				addresses[containerAddress] = net.JoinHostPort(localHost(port.HostIP), port.Published)
+			}
+		}
+	}
+
+	return addresses
+}
+
+// serviceHosts returns host names service name is reached at from other containers.
+func serviceHosts(project composeProject, name string, service composeService) []string {
+	hosts := []string{
+		project.Name + "-" + name + "-1",
+		project.Name + "_" + name + "_1",
+		name,
+	}
+
+	if service.ContainerName != "" {
+		hosts = append(hosts, service.ContainerName)
+	}
+
+	return hosts
+}
+
+// runPackage returns the package to run, [RunPackage] if set, cmd/<app name> at the project root
+// otherwise.
+func runPackage(env map[string]string) (string, error) {
+	if RunPackage != "" {
+		return RunPackage, nil
+	}
+
+	root, err := projectRoot()
+	if err != nil {
+		return "", err
+	}
+
+	pkg := filepath.Join(root, "cmd", env[appNameEnvVarKey])
+
+	_, err = os.Stat(pkg)
+	if err != nil {
+		return "", fmt.Errorf("error finding package to run, set it with --package: %w", err)
+	}
+
+	return pkg, nil
+}
+
+// mergeEnv returns environ, as KEY=VALUE, with env variables set, overriding existing ones.
+func mergeEnv(environ []string, env map[string]string) []string {
+	merged := slices.DeleteFunc(slices.Clone(environ), func(entry string) bool {
+		key, _, _ := strings.Cut(entry, "=")
+		_, ok := env[key]
+
+		return ok
+	})
+
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		merged = append(merged, key+"="+env[key])
+	}
+
+	return merged
+}
+
+// shellQuote quotes value for POSIX shells.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// dotenvQuote quotes value for .env files, when it contains characters needing it.
+func dotenvQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\"'#$\\`") {
+		return value
+	}
+
+	return strconv.Quote(value)
+}
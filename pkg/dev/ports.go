@@ -0,0 +1,431 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrPortsInUse = errors.New("published ports already in use")
+
+// AutoPorts is a flag to remap published ports already in use to free ones.
+//
+//nolint:gochecknoglobals // Cobra flags are global
+var AutoPorts bool
+
+// portConflict is a published port already in use on the host.
+type portConflict struct {
+	service string
+	port    composePort
+	owner   string
+}
+
+// portsOverridePath returns the path of the compose override file remapping ports of the project
+// at root.
+func portsOverridePath(root string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting cache directory: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(root))
+
+	return filepath.Join(cacheDir, "kemutil", "compose", hex.EncodeToString(sum[:])[:16]+".ports.override.yaml"), nil
+}
+
+// portsOverride is the compose override file remapping ports, as written by [remapPorts].
+type portsOverride struct {
+	Services map[string]struct {
+		Ports []string `yaml:"ports"`
+	} `yaml:"services"`
+}
+
+// existingPortsOverride returns the path of the ports override file of the project, or an empty
+// string if ports are not remapped.
+func existingPortsOverride() (string, error) {
+	root, err := projectRoot()
+	if err != nil {
+		return "", err
+	}
+
+	overridePath, err := portsOverridePath(root)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = os.Stat(overridePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("error checking ports override: %w", err)
+	}
+
+	return overridePath, nil
+}
+
+// checkPorts makes sure ports published by services are free, remapping them to free ones if
+// [AutoPorts] is set, and failing with a report otherwise.
+func checkPorts() error {
+	project, err := loadProject()
+	if err != nil {
+		return err
+	}
+
+	containers, err := listContainers()
+	if err != nil {
+		return err
+	}
+
+	conflicts := findPortConflicts(project, containers)
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	if !AutoPorts {
+		printPortConflicts(conflicts)
+
+		return fmt.Errorf("stop conflicting processes, or remap ports with --auto-ports: %w", ErrPortsInUse)
+	}
+
+	return remapPorts(project, conflicts)
+}
+
+// findPortConflicts returns TCP ports published by services that are in use on the host, ports
+// held by the project own containers excepted.
+func findPortConflicts(project composeProject, containers []composeContainer) []portConflict {
+	owned := map[string]struct{}{}
+
+	for _, container := range containers {
+		for _, publisher := range container.Publishers {
+			owned[strconv.Itoa(publisher.PublishedPort)] = struct{}{}
+		}
+	}
+
+	conflicts := []portConflict{}
+
+	for _, name := range slices.Sorted(maps.Keys(project.Services)) {
+		for _, port := range project.Services[name].Ports {
+			if port.Published == "" || (port.Protocol != "" && port.Protocol != "tcp") {
+				continue
+			}
+
+			if _, ok := owned[port.Published]; ok {
+				continue
+			}
+
+			listener, err := net.Listen("tcp", net.JoinHostPort(port.HostIP, port.Published))
+			if err == nil {
+				listener.Close()
+
+				continue
+			}
+
+			slog.Debug("Port in use", slog.String("service", name), slog.String("port", port.Published), slog.String("error", err.Error()))
+
+			conflicts = append(conflicts, portConflict{
+				service: name,
+				port:    port,
+				owner:   portOwner(port.Published),
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// portOwner returns a description of what listens on TCP port, or "unknown".
+func portOwner(port string) string {
+	com, err := dockerCommand("ps", "--filter", "publish="+port, "--format", "{{.Names}}")
+	if err == nil {
+		com.Stderr = nil
+
+		out, err := com.Output()
+		if err == nil && strings.TrimSpace(string(out)) != "" {
+			return "container " + strings.Join(strings.Fields(string(out)), ", ")
+		}
+	}
+
+	owner, err := procPortOwner(port)
+	if err == nil && owner != "" {
+		return owner
+	}
+
+	binary, err := exec.LookPath("lsof")
+	if err != nil {
+		return "unknown"
+	}
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	out, err := exec.Command(binary, "-nP", "-iTCP:"+port, "-sTCP:LISTEN", "-Fpc").Output()
+	if err == nil {
+		pid, command := "", ""
+
+		for _, line := range strings.Split(string(out), "\n") {
+			switch {
+			case strings.HasPrefix(line, "p") && pid == "":
+				pid = line[1:]
+			case strings.HasPrefix(line, "c") && command == "":
+				command = line[1:]
+			}
+		}
+
+		if pid != "" {
+			return command + " (pid " + pid + ")"
+		}
+	}
+
+	return "unknown"
+}
+
+// procPortOwner returns the process listening on TCP port, using procfs. Processes of other users
+// cannot be identified without privileges.
+func procPortOwner(port string) (string, error) {
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return "", fmt.Errorf("invalid port %q: %w", port, err)
+	}
+
+	hexPort := fmt.Sprintf(":%04X", portNum)
+	inodes := map[string]struct{}{}
+
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		file, err := os.Open(table)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			// Fields are sl, local_address, rem_address, st, ..., inode at index 9, 0A being LISTEN
+			if len(fields) < 10 || !strings.HasSuffix(fields[1], hexPort) || fields[3] != "0A" {
+				continue
+			}
+
+			inodes["socket:["+fields[9]+"]"] = struct{}{}
+		}
+
+		file.Close()
+	}
+
+	if len(inodes) == 0 {
+		return "", nil
+	}
+
+	pids, err := filepath.Glob("/proc/[0-9]*")
+	if err != nil {
+		return "", fmt.Errorf("error listing processes: %w", err)
+	}
+
+	for _, pidDir := range pids {
+		fds, err := os.ReadDir(filepath.Join(pidDir, "fd"))
+		if err != nil {
+			continue
+		}
+
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(pidDir, "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+
+			if _, ok := inodes[target]; !ok {
+				continue
+			}
+
+			comm, err := os.ReadFile(filepath.Join(pidDir, "comm"))
+			if err != nil {
+				return "pid " + filepath.Base(pidDir), nil
+			}
+
+			return strings.TrimSpace(string(comm)) + " (pid " + filepath.Base(pidDir) + ")", nil
+		}
+	}
+
+	return "", nil
+}
+
+func printPortConflicts(conflicts []portConflict) {
+	writer := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "SERVICE\tPORT\tUSED BY")
+
+	for _, conflict := range conflicts {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", conflict.service, conflict.port.Published, conflict.owner)
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		slog.Warn("Error writing port conflicts", slog.String("error", err.Error()))
+	}
+}
+
+// freePort returns a TCP port free on the host.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, fmt.Errorf("error finding a free port: %w", err)
+	}
+	defer listener.Close()
+
+	addr, ok := listener.Addr().(*net.TCPAddr)
+	if !ok {
+		return 0, fmt.Errorf("unexpected listener address %s: %w", listener.Addr(), ErrPortsInUse)
+	}
+
+	return addr.Port, nil
+}
+
+// remapPorts writes a compose override file publishing conflicting ports on free ones, used by
+// later compose commands until the environment is shut down. Ports previously remapped are kept.
+func remapPorts(project composeProject, conflicts []portConflict) error {
+	root, err := projectRoot()
+	if err != nil {
+		return err
+	}
+
+	overridePath, err := portsOverridePath(root)
+	if err != nil {
+		return err
+	}
+
+	remapped := map[string]map[string]int{}
+
+	for _, conflict := range conflicts {
+		port, err := freePort()
+		if err != nil {
+			return err
+		}
+
+		if remapped[conflict.service] == nil {
+			remapped[conflict.service] = map[string]int{}
+		}
+
+		remapped[conflict.service][conflict.port.Published] = port
+
+		slog.Info(
+			"Remapping port in use",
+			slog.String("service", conflict.service),
+			slog.String("port", conflict.port.Published),
+			slog.Int("remappedPort", port),
+			slog.String("usedBy", conflict.owner),
+		)
+	}
+
+	services := map[string]any{}
+
+	previous := portsOverride{}
+
+	content, err := os.ReadFile(overridePath)
+	if err == nil {
+		err = yaml.Unmarshal(content, &previous)
+		if err != nil {
+			return fmt.Errorf("error parsing ports override: %w", err)
+		}
+	}
+
+	for name, service := range previous.Services {
+		services[name] = map[string]any{
+			"ports": &yaml.Node{Kind: yaml.SequenceNode, Tag: "!override", Content: stringNodes(service.Ports)},
+		}
+	}
+
+	for name, ports := range remapped {
+		specs := []string{}
+
+		for _, port := range project.Services[name].Ports {
+			published := port.Published
+			if remappedPort, ok := ports[published]; ok {
+				published = strconv.Itoa(remappedPort)
+			}
+
+			spec := published + ":" + strconv.Itoa(port.Target)
+			if port.HostIP != "" {
+				spec = port.HostIP + ":" + spec
+			}
+
+			if port.Protocol != "" && port.Protocol != "tcp" {
+				spec += "/" + port.Protocol
+			}
+
+			specs = append(specs, spec)
+		}
+
+		// Tagged so that ports replace, rather than add to, the ones of layered files
+		services[name] = map[string]any{
+			"ports": &yaml.Node{
+				Kind:    yaml.SequenceNode,
+				Tag:     "!override",
+				Content: stringNodes(specs),
+			},
+		}
+	}
+
+	content, err = yaml.Marshal(map[string]any{"services": services})
+	if err != nil {
+		return fmt.Errorf("error marshalling ports override: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(overridePath), 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+
+	err = os.WriteFile(overridePath, content, 0o644)
+	if err != nil {
+		return fmt.Errorf("error writing ports override: %w", err)
+	}
+
+	slog.Debug("Wrote ports override", slog.String("path", overridePath))
+
+	return nil
+}
+
+func stringNodes(values []string) []*yaml.Node {
+	nodes := make([]*yaml.Node, 0, len(values))
+
+	for _, value := range values {
+		nodes = append(nodes, &yaml.Node{Kind: yaml.ScalarNode, Value: value, Style: yaml.DoubleQuotedStyle})
+	}
+
+	return nodes
+}
+
+// removePortsOverride removes the ports override file of the project, if any.
+func removePortsOverride() error {
+	root, err := projectRoot()
+	if err != nil {
+		return err
+	}
+
+	overridePath, err := portsOverridePath(root)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(overridePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing ports override: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kemadev/kemutil/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// ListProfiles lists compose profiles declared by services, along with whether they are enabled.
+func ListProfiles(_ *cobra.Command, _ []string) error {
+	slog.Debug("Listing compose profiles")
+
+	conf, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	project, err := loadFullProject(conf.Dev)
+	if err != nil {
+		return err
+	}
+
+	services := map[string][]string{}
+	always := []string{}
+
+	for _, name := range slices.Sorted(maps.Keys(project.Services)) {
+		serviceProfiles := project.Services[name].Profiles
+		if len(serviceProfiles) == 0 {
+			always = append(always, name)
+
+			continue
+		}
+
+		for _, profile := range serviceProfiles {
+			services[profile] = append(services[profile], name)
+		}
+	}
+
+	enabled := profiles(conf.Dev)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "PROFILE\tENABLED\tSERVICES")
+
+	for _, profile := range slices.Sorted(maps.Keys(services)) {
+		state := "no"
+		if slices.Contains(enabled, profile) {
+			state = "yes"
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", profile, state, strings.Join(services[profile], ", "))
+	}
+
+	if len(always) > 0 {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", "(none)", "always", strings.Join(always, ", "))
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing profiles list: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/config"
+)
+
+// composeProject is the resolved compose model, as output by `docker compose config`.
+type composeProject struct {
+	Name     string                    `json:"name"`
+	Services map[string]composeService `json:"services"`
+	Volumes  map[string]composeVolume  `json:"volumes"`
+}
+
+// composeService is a service of the compose model.
+type composeService struct {
+	Image         string                 `json:"image"`
+	ContainerName string                 `json:"container_name"`
+	Profiles      []string               `json:"profiles"`
+	Environment   map[string]*string     `json:"environment"`
+	Ports         []composePort          `json:"ports"`
+	Healthcheck   *composeHealthcheck    `json:"healthcheck"`
+	Volumes       []composeServiceVolume `json:"volumes"`
+}
+
+// composePort is a port of a compose service.
+type composePort struct {
+	Target    int    `json:"target"`
+	Published string `json:"published"`
+	HostIP    string `json:"host_ip"`
+	Protocol  string `json:"protocol"`
+}
+
+// composeHealthcheck is the healthcheck of a compose service.
+type composeHealthcheck struct {
+	Test    []string `json:"test"`
+	Disable bool     `json:"disable"`
+}
+
+// composeServiceVolume is a volume mounted in a compose service.
+type composeServiceVolume struct {
+	Type   string `json:"type"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// composeVolume is a named volume of the compose model.
+type composeVolume struct {
+	Name     string `json:"name"`
+	External bool   `json:"external"`
+}
+
+// env returns the value of environment variable key of the service, and false if unset.
+func (s composeService) env(key string) (string, bool) {
+	value, ok := s.Environment[key]
+	if !ok || value == nil {
+		return "", false
+	}
+
+	return *value, true
+}
+
+// hasHealthcheck reports whether the service defines an enabled healthcheck.
+func (s composeService) hasHealthcheck() bool {
+	return s.Healthcheck != nil && !s.Healthcheck.Disable && len(s.Healthcheck.Test) > 0 &&
+		s.Healthcheck.Test[0] != "NONE"
+}
+
+// composeCommand returns a docker compose command run with given subcommand arguments after
+// profiles and compose files selection, attached to the standard error.
+func composeCommand(subArgs ...string) (*exec.Cmd, error) {
+	baseArgs, err := composeArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	return dockerCommand(append(baseArgs, subArgs...)...)
+}
+
+// dockerCommand returns a docker command run with given arguments, attached to the standard error.
+func dockerCommand(baseArgs ...string) (*exec.Cmd, error) {
+	binary, err := exec.LookPath("docker")
+	if err != nil {
+		return nil, fmt.Errorf("docker binary not found: %w", err)
+	}
+
+	slog.Debug("Running command", slog.Any("binary", binary), slog.Any("baseArgs", baseArgs))
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	com := exec.Command(binary, baseArgs...)
+	com.Stderr = os.Stderr
+
+	return com, nil
+}
+
+// runCompose runs docker compose with given subcommand arguments, attached to the standard streams.
+func runCompose(subArgs ...string) error {
+	com, err := composeCommand(subArgs...)
+	if err != nil {
+		return err
+	}
+
+	com.Stdin = os.Stdin
+	com.Stdout = os.Stdout
+
+	err = com.Run()
+	if err != nil {
+		return fmt.Errorf("error running docker compose %s: %w", subArgs[0], err)
+	}
+
+	return nil
+}
+
+// composeOutput runs docker compose with given subcommand arguments, and returns its output.
+func composeOutput(subArgs ...string) ([]byte, error) {
+	com, err := composeCommand(subArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := com.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running docker compose %s: %w", subArgs[0], err)
+	}
+
+	return out, nil
+}
+
+// loadProject returns the compose model, with overrides layered and enabled profiles applied.
+func loadProject() (composeProject, error) {
+	out, err := composeOutput("config", "--format", "json")
+	if err != nil {
+		return composeProject{}, err
+	}
+
+	return parseProject(out)
+}
+
+// loadFullProject returns the compose model with all profiles enabled, so that all services,
+// including templates never started, are part of it.
+func loadFullProject(conf config.Dev) (composeProject, error) {
+	baseArgs, err := composeArgsFor(conf, []string{"*"})
+	if err != nil {
+		return composeProject{}, err
+	}
+
+	com, err := dockerCommand(append(baseArgs, "config", "--format", "json")...)
+	if err != nil {
+		return composeProject{}, err
+	}
+
+	out, err := com.Output()
+	if err != nil {
+		return composeProject{}, fmt.Errorf("error running docker compose config: %w", err)
+	}
+
+	return parseProject(out)
+}
+
+func parseProject(out []byte) (composeProject, error) {
+	project := composeProject{}
+
+	err := json.Unmarshal(out, &project)
+	if err != nil {
+		return composeProject{}, fmt.Errorf("error parsing compose model: %w", err)
+	}
+
+	return project, nil
+}
+
+// composeContainer is a container of the compose project, as output by `docker compose ps`.
+type composeContainer struct {
+	Name       string             `json:"Name"`
+	Service    string             `json:"Service"`
+	State      string             `json:"State"`
+	Health     string             `json:"Health"`
+	ExitCode   int                `json:"ExitCode"`
+	Publishers []composePublisher `json:"Publishers"`
+}
+
+// composePublisher is a port published by a container of the compose project.
+type composePublisher struct {
+	URL           string `json:"URL"`
+	TargetPort    int    `json:"TargetPort"`
+	PublishedPort int    `json:"PublishedPort"`
+	Protocol      string `json:"Protocol"`
+}
+
+// listContainers returns containers of the compose project, including stopped ones.
+func listContainers() ([]composeContainer, error) {
+	out, err := composeOutput("ps", "--all", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	out = bytes.TrimSpace(out)
+
+	containers := []composeContainer{}
+
+	// Older compose versions output an array, newer ones a container per line
+	if bytes.HasPrefix(out, []byte("[")) {
+		err := json.Unmarshal(out, &containers)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing containers list: %w", err)
+		}
+
+		return containers, nil
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		container := composeContainer{}
+
+		err := json.Unmarshal([]byte(line), &container)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing containers list: %w", err)
+		}
+
+		containers = append(containers, container)
+	}
+
+	return containers, nil
+}
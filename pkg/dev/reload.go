@@ -0,0 +1,316 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kemadev/kemutil/internal/pollwatch"
+)
+
+const (
+	// reloadPollInterval is the interval sources are checked for changes at.
+	reloadPollInterval = 500 * time.Millisecond
+	// reloadDebounce is how long sources must stay unchanged before the application is rebuilt.
+	reloadDebounce = 300 * time.Millisecond
+)
+
+var (
+	// reloadAssetDirs are directories, relative to the repository root, whose files are embedded in
+	// the application, as served by web.GetStaticFS and web.GetTmplFS.
+	//nolint:gochecknoglobals // Used as a const
+	reloadAssetDirs = []string{"web/static", "web/tmpl"}
+	// reloadSourceFiles are files, other than Go sources, that affect builds.
+	//nolint:gochecknoglobals // Used as a const
+	reloadSourceFiles = []string{"go.mod", "go.sum", "go.work", "go.work.sum"}
+)
+
+// GracePeriod is a flag to set how long the application is given to shut down on restarts.
+//
+//nolint:gochecknoglobals // Cobra flags are global
+var GracePeriod time.Duration
+
+// reloader builds and runs the application, rebuilding and restarting it when sources change.
+type reloader struct {
+	goBinary string
+	pkg      string
+	args     []string
+	env      []string
+	root     string
+	buildDir string
+	builds   int
+	// binary is the path of the running application binary, empty if none was built.
+	binary  string
+	process *exec.Cmd
+	// exited receives the result of the running application, and is nil when none runs.
+	exited chan error
+}
+
+// runLive runs pkg with env and args, rebuilding and gracefully restarting it when Go sources or
+// embedded assets change, until interrupted. When a build fails, the previous binary keeps running.
+func runLive(goBinary string, pkg string, args []string, env []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	root, err := projectRoot()
+	if err != nil {
+		return err
+	}
+
+	buildDir, err := os.MkdirTemp("", "kemutil-run-")
+	if err != nil {
+		return fmt.Errorf("error creating build directory: %w", err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	r := &reloader{
+		goBinary: goBinary,
+		pkg:      pkg,
+		args:     args,
+		env:      env,
+		root:     root,
+		buildDir: buildDir,
+	}
+	defer r.stop()
+
+	return r.loop(ctx)
+}
+
+// loop builds and starts the application, then watches sources until ctx is done.
+func (r *reloader) loop(ctx context.Context) error {
+	poller, err := pollwatch.New(r.snapshot, reloadDebounce)
+	if err != nil {
+		return err
+	}
+
+	r.reload()
+
+	slog.Info("Watching sources for changes, press Ctrl+C to stop", slog.String("dir", r.root))
+
+	ticker := time.NewTicker(reloadPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping application")
+
+			return nil
+		case err := <-r.exited:
+			r.exited = nil
+
+			if err != nil {
+				slog.Error("Application exited, waiting for changes", slog.String("error", err.Error()))
+			} else {
+				slog.Info("Application exited, waiting for changes")
+			}
+
+			continue
+		case <-ticker.C:
+		}
+
+		files, err := poller.Poll()
+		if err != nil {
+			slog.Warn("Error listing sources", slog.String("error", err.Error()))
+
+			continue
+		}
+
+		if len(files) == 0 {
+			continue
+		}
+
+		slog.Info("Sources changed, rebuilding", slog.Any("files", files))
+
+		r.reload()
+	}
+}
+
+// reload builds the application, then restarts it with the new binary if the build succeeded.
+func (r *reloader) reload() {
+	binary, err := r.build()
+	if err != nil {
+		if r.exited != nil {
+			slog.Error("Build failed, previous binary keeps running", slog.String("error", err.Error()))
+		} else {
+			slog.Error("Build failed", slog.String("error", err.Error()))
+		}
+
+		return
+	}
+
+	r.stop()
+
+	if r.binary != "" {
+		err := os.Remove(r.binary)
+		if err != nil {
+			slog.Warn("Error removing previous binary", slog.String("error", err.Error()))
+		}
+	}
+
+	r.binary = binary
+
+	err = r.start()
+	if err != nil {
+		slog.Error("Error starting application", slog.String("error", err.Error()))
+	}
+}
+
+// build builds the application into a new binary, leaving the running one untouched, and returns
+// its path. Builds are incremental, thanks to the go build cache.
+func (r *reloader) build() (string, error) {
+	r.builds++
+
+	binary := filepath.Join(r.buildDir, "app-"+strconv.Itoa(r.builds))
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+
+	baseArgs := []string{"build", "-o", binary, r.pkg}
+
+	slog.Debug("Running command", slog.Any("binary", r.goBinary), slog.Any("baseArgs", baseArgs))
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	com := exec.Command(r.goBinary, baseArgs...)
+	com.Stdout = os.Stdout
+	com.Stderr = os.Stderr
+
+	err := com.Run()
+	if err != nil {
+		return "", fmt.Errorf("error building %s: %w", r.pkg, err)
+	}
+
+	return binary, nil
+}
+
+// start starts the current binary.
+func (r *reloader) start() error {
+	slog.Info("Starting application", slog.String("package", r.pkg))
+
+	// nosemgrep: gitlab.gosec.G204-1 // Binary is the one just built from the repository
+	com := exec.Command(r.binary, r.args...)
+	com.Env = r.env
+	com.Stdin = os.Stdin
+	com.Stdout = os.Stdout
+	com.Stderr = os.Stderr
+
+	err := com.Start()
+	if err != nil {
+		return fmt.Errorf("error starting %s: %w", r.binary, err)
+	}
+
+	exited := make(chan error, 1)
+
+	go func() {
+		exited <- com.Wait()
+	}()
+
+	r.process = com
+	r.exited = exited
+
+	return nil
+}
+
+// stop asks the running application, if any, to shut down, and kills it after [GracePeriod].
+func (r *reloader) stop() {
+	if r.exited == nil {
+		return
+	}
+
+	exited := r.exited
+	r.exited = nil
+
+	// Signals other than kill are not supported on Windows, where the application is killed right away
+	err := r.process.Process.Signal(syscall.SIGTERM)
+	if err == nil {
+		select {
+		case <-exited:
+			return
+		case <-time.After(GracePeriod):
+		}
+
+		slog.Warn("Application did not shut down in time, killing it", slog.Duration("gracePeriod", GracePeriod))
+	}
+
+	err = r.process.Process.Kill()
+	if err != nil && !errors.Is(err, os.ErrProcessDone) {
+		slog.Warn("Error killing application", slog.String("error", err.Error()))
+	}
+
+	<-exited
+}
+
+// snapshot returns the state of Go sources and embedded assets under the repository root, keyed
+// by root-relative path. Hidden directories and vendored dependencies are skipped.
+func (r *reloader) snapshot() (map[string]pollwatch.State, error) {
+	states := map[string]pollwatch.State{}
+
+	err := filepath.WalkDir(r.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		rel, err := filepath.Rel(r.root, path)
+		if err != nil {
+			return fmt.Errorf("error getting relative path: %w", err)
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if entry.IsDir() {
+			if rel != "." && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "vendor" || entry.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !isReloadSource(rel) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+
+		states[rel] = pollwatch.StateOf(info)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking sources: %w", err)
+	}
+
+	return states, nil
+}
+
+// isReloadSource reports whether changes to file, relative to the repository root, require a rebuild.
+func isReloadSource(file string) bool {
+	if strings.HasSuffix(file, ".go") || slices.Contains(reloadSourceFiles, file) {
+		return true
+	}
+
+	return slices.ContainsFunc(reloadAssetDirs, func(dir string) bool {
+		return strings.HasPrefix(file, dir+"/")
+	})
+}
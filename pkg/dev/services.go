@@ -0,0 +1,115 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/kemadev/ci-cd/pkg/auth"
+	"github.com/kemadev/kemutil/internal/credential"
+	"github.com/spf13/cobra"
+)
+
+// Follow is a flag to follow logs output.
+//
+//nolint:gochecknoglobals // Cobra flags are global
+var Follow bool
+
+// execCompose replaces the current process with docker compose, run with given subcommand
+// arguments after profiles and compose files selection.
+func execCompose(subArgs ...string) error {
+	binary, err := exec.LookPath("docker")
+	if err != nil {
+		return fmt.Errorf("docker binary not found: %w", err)
+	}
+
+	baseArgs, err := composeArgs()
+	if err != nil {
+		return err
+	}
+
+	baseArgs = append(baseArgs, subArgs...)
+
+	slog.Debug("Running command", slog.Any("binary", binary), slog.Any("baseArgs", baseArgs))
+
+	// nosemgrep: go.lang.security.audit.dangerous-syscall-exec.dangerous-syscall-exec // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	err = syscall.Exec(binary, append([]string{binary}, baseArgs...), os.Environ())
+	if err != nil {
+		return fmt.Errorf("error running docker compose command: %w", err)
+	}
+
+	return nil
+}
+
+// exportNetrc exports the netrc environment variable used by image builds, if [ExportNetrc] is set.
+func exportNetrc() error {
+	if !ExportNetrc {
+		return nil
+	}
+
+	netrc, err := credential.RemoteNetrc()
+	if err != nil {
+		return fmt.Errorf("error getting netrc: %w", err)
+	}
+
+	os.Setenv(auth.NetrcEnvVarKey, netrc)
+
+	return nil
+}
+
+// Ps lists services of the local development environment.
+func Ps(_ *cobra.Command, _ []string) error {
+	slog.Debug("Listing local development services")
+
+	return execCompose("ps", "--all")
+}
+
+// Logs shows logs of given services, or of all services.
+func Logs(_ *cobra.Command, args []string) error {
+	slog.Debug("Showing local development services logs", slog.Any("services", args))
+
+	subArgs := []string{"logs"}
+	if Follow {
+		subArgs = append(subArgs, "--follow")
+	}
+
+	return execCompose(append(subArgs, args...)...)
+}
+
+// Exec runs a command in a running service container.
+func Exec(_ *cobra.Command, args []string) error {
+	slog.Debug("Running command in service", slog.String("service", args[0]), slog.Any("command", args[1:]))
+
+	subArgs := []string{"exec"}
+
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		subArgs = append(subArgs, "--no-TTY")
+	}
+
+	return execCompose(append(subArgs, args...)...)
+}
+
+// Restart restarts given services.
+func Restart(_ *cobra.Command, args []string) error {
+	slog.Info("Restarting services", slog.Any("services", args))
+
+	return execCompose(append([]string{"restart"}, args...)...)
+}
+
+// Build builds images of given services, or of all services.
+func Build(_ *cobra.Command, args []string) error {
+	slog.Info("Building services images", slog.Any("services", args))
+
+	err := exportNetrc()
+	if err != nil {
+		return err
+	}
+
+	return execCompose(append([]string{"build"}, args...)...)
+}
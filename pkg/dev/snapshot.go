@@ -0,0 +1,529 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kemadev/kemutil/internal/config"
+	"github.com/kemadev/kemutil/internal/units"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// snapshotImage is the image volumes are archived and restored with.
+	snapshotImage = "docker.io/alpine:3.22.1@sha256:4bcff63911fcb4448bd4fdacec207030997caf25e9bea4045fa6c8c44de311d1"
+	// snapshotArchiveExt is the extension of volume archives, named after the compose volume key.
+	snapshotArchiveExt = ".tar.gz"
+	// seedDir is the directory, relative to the repository root, seed scripts are run from.
+	seedDir = "tool/dev/seed"
+)
+
+var (
+	ErrSnapshotNameInvalid = errors.New("invalid snapshot name")
+	ErrSnapshotNotFound    = errors.New("snapshot not found")
+	ErrNoVolumes           = errors.New("no volumes in compose project")
+	ErrVolumeNotCreated    = errors.New("volume not created")
+)
+
+// snapshotNameRegexp matches valid snapshot names, usable as directory names.
+//
+//nolint:gochecknoglobals // Used as a const
+var snapshotNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// snapshotVolume is a named volume of the compose project.
+type snapshotVolume struct {
+	// key is the volume key in the compose file, such as postgresql.
+	key string
+	// name is the docker volume name, such as kemadev-kemutil_postgresql.
+	name string
+}
+
+// projectVolumes returns the compose project, along with its named volumes, external ones excepted.
+func projectVolumes() (composeProject, []snapshotVolume, error) {
+	conf, err := config.Load()
+	if err != nil {
+		return composeProject{}, nil, fmt.Errorf("error loading config: %w", err)
+	}
+
+	project, err := loadFullProject(conf.Dev)
+	if err != nil {
+		return composeProject{}, nil, err
+	}
+
+	volumes := []snapshotVolume{}
+
+	for _, key := range slices.Sorted(maps.Keys(project.Volumes)) {
+		volume := project.Volumes[key]
+		if volume.External {
+			continue
+		}
+
+		volumes = append(volumes, snapshotVolume{key: key, name: volume.Name})
+	}
+
+	if len(volumes) == 0 {
+		return composeProject{}, nil, ErrNoVolumes
+	}
+
+	return project, volumes, nil
+}
+
+// snapshotsDir returns the directory snapshots of project are stored in.
+func snapshotsDir(project string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting cache directory: %w", err)
+	}
+
+	return filepath.Join(cacheDir, "kemutil", "snapshots", project), nil
+}
+
+// snapshotDir returns the directory of snapshot name of project.
+func snapshotDir(project string, name string) (string, error) {
+	if !snapshotNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("%q, expected letters, digits, '.', '_' or '-': %w", name, ErrSnapshotNameInvalid)
+	}
+
+	dir, err := snapshotsDir(project)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// SnapshotSave archives named volumes of the compose project as a snapshot, replacing any snapshot
+// of the same name. Services are stopped while volumes are archived. All volumes must have been
+// created, so that a snapshot is never silently partial.
+func SnapshotSave(_ *cobra.Command, args []string) error {
+	project, volumes, err := projectVolumes()
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+
+	for _, volume := range volumes {
+		exists, err := volumeExists(volume.name)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			missing = append(missing, volume.key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%s, start services first: %w", strings.Join(missing, ", "), ErrVolumeNotCreated)
+	}
+
+	dir, err := snapshotDir(project.Name, args[0])
+	if err != nil {
+		return err
+	}
+
+	// Written aside, so that a failed save leaves the previous snapshot untouched
+	tmpDir := filepath.Join(filepath.Dir(dir), "."+args[0]+".tmp")
+
+	err = os.RemoveAll(tmpDir)
+	if err != nil {
+		return fmt.Errorf("error cleaning snapshot directory: %w", err)
+	}
+
+	err = os.MkdirAll(tmpDir, 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	err = withServicesStopped(func() error {
+		for _, volume := range volumes {
+			err := saveVolume(volume, filepath.Join(tmpDir, volume.key+snapshotArchiveExt))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return fmt.Errorf("error removing previous snapshot: %w", err)
+	}
+
+	err = os.Rename(tmpDir, dir)
+	if err != nil {
+		return fmt.Errorf("error saving snapshot: %w", err)
+	}
+
+	slog.Info("Saved snapshot", slog.String("snapshot", args[0]), slog.String("path", dir))
+
+	return nil
+}
+
+// SnapshotRestore replaces the content of named volumes of the compose project with the ones of a
+// snapshot. Services are stopped while volumes are restored.
+func SnapshotRestore(_ *cobra.Command, args []string) error {
+	project, volumes, err := projectVolumes()
+	if err != nil {
+		return err
+	}
+
+	dir, err := snapshotDir(project.Name, args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], ErrSnapshotNotFound)
+	}
+
+	return withServicesStopped(func() error {
+		for _, volume := range volumes {
+			archive := filepath.Join(dir, volume.key+snapshotArchiveExt)
+
+			_, err := os.Stat(archive)
+			if err != nil {
+				slog.Warn("Volume not in snapshot, leaving it untouched", slog.String("volume", volume.key))
+
+				continue
+			}
+
+			err = restoreVolume(project.Name, volume, archive)
+			if err != nil {
+				return err
+			}
+		}
+
+		slog.Info("Restored snapshot", slog.String("snapshot", args[0]))
+
+		return nil
+	})
+}
+
+// SnapshotList lists snapshots of the compose project.
+func SnapshotList(_ *cobra.Command, _ []string) error {
+	project, _, err := projectVolumes()
+	if err != nil {
+		return err
+	}
+
+	dir, err := snapshotsDir(project.Name)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error listing snapshots: %w", err)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "NAME\tCREATED\tSIZE\tVOLUMES")
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		archives, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("error reading snapshot %s: %w", entry.Name(), err)
+		}
+
+		size := int64(0)
+		volumes := []string{}
+
+		for _, archive := range archives {
+			archiveInfo, err := archive.Info()
+			if err != nil {
+				continue
+			}
+
+			size += archiveInfo.Size()
+			volumes = append(volumes, strings.TrimSuffix(archive.Name(), snapshotArchiveExt))
+		}
+
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\n",
+			entry.Name(),
+			info.ModTime().Format(time.DateTime),
+			units.HumanSize(size),
+			strings.Join(volumes, ", "),
+		)
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing snapshots list: %w", err)
+	}
+
+	return nil
+}
+
+// SnapshotDelete deletes a snapshot of the compose project.
+func SnapshotDelete(_ *cobra.Command, args []string) error {
+	project, _, err := projectVolumes()
+	if err != nil {
+		return err
+	}
+
+	dir, err := snapshotDir(project.Name, args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], ErrSnapshotNotFound)
+	}
+
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return fmt.Errorf("error deleting snapshot: %w", err)
+	}
+
+	slog.Info("Deleted snapshot", slog.String("snapshot", args[0]))
+
+	return nil
+}
+
+// Reset drops named volumes of the compose project, starts services again, applies database
+// migrations and fixtures, and runs seed scripts.
+func Reset(_ *cobra.Command, _ []string) error {
+	slog.Info("Resetting local development environment")
+
+	err := runCompose("down", "--volumes")
+	if err != nil {
+		return err
+	}
+
+	err = exportNetrc()
+	if err != nil {
+		return err
+	}
+
+	err = checkPorts()
+	if err != nil {
+		return err
+	}
+
+	// Seeds need services to accept connections
+	err = startDetached(true)
+	if err != nil {
+		return err
+	}
+
+	err = seedDatabase()
+	if err != nil {
+		return err
+	}
+
+	return runSeeds()
+}
+
+// withServicesStopped runs fn with running services of the compose project stopped, and starts
+// them again afterwards.
+func withServicesStopped(fn func() error) error {
+	containers, err := listContainers()
+	if err != nil {
+		return err
+	}
+
+	running := slices.ContainsFunc(containers, func(container composeContainer) bool {
+		return container.State == "running"
+	})
+
+	if running {
+		slog.Info("Stopping services")
+
+		err := runCompose("stop")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = fn()
+
+	if running {
+		slog.Info("Starting services")
+
+		startErr := runCompose("start")
+		if startErr != nil {
+			return errors.Join(err, startErr)
+		}
+	}
+
+	return err
+}
+
+// saveVolume archives the content of volume to path.
+func saveVolume(volume snapshotVolume, path string) error {
+	slog.Info("Saving volume", slog.String("volume", volume.key))
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating volume archive: %w", err)
+	}
+	defer file.Close()
+
+	// Archive is streamed rather than written to a bind mount, so that it is owned by the user
+	com, err := dockerCommand("run", "--rm", "-v", volume.name+":/volume:ro", snapshotImage, "tar", "-czf", "-", "-C", "/volume", ".")
+	if err != nil {
+		return err
+	}
+
+	com.Stdout = file
+
+	err = com.Run()
+	if err != nil {
+		return fmt.Errorf("error archiving volume %s: %w", volume.key, err)
+	}
+
+	return nil
+}
+
+// restoreVolume replaces the content of volume with the one of archive at path, creating the
+// volume if needed.
+func restoreVolume(project string, volume snapshotVolume, path string) error {
+	slog.Info("Restoring volume", slog.String("volume", volume.key))
+
+	exists, err := volumeExists(volume.name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		// Labeled as compose does, so that it adopts the volume
+		com, err := dockerCommand(
+			"volume", "create",
+			"--label", "com.docker.compose.project="+project,
+			"--label", "com.docker.compose.volume="+volume.key,
+			volume.name,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = com.Run()
+		if err != nil {
+			return fmt.Errorf("error creating volume %s: %w", volume.key, err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening volume archive: %w", err)
+	}
+	defer file.Close()
+
+	com, err := dockerCommand(
+		"run", "--rm", "--interactive", "-v", volume.name+":/volume", snapshotImage,
+		"sh", "-c", "find /volume -mindepth 1 -delete && tar -xzf - -C /volume",
+	)
+	if err != nil {
+		return err
+	}
+
+	com.Stdin = file
+
+	err = com.Run()
+	if err != nil {
+		return fmt.Errorf("error restoring volume %s: %w", volume.key, err)
+	}
+
+	return nil
+}
+
+// volumeExists reports whether docker volume name exists.
+func volumeExists(name string) (bool, error) {
+	com, err := dockerCommand("volume", "inspect", name)
+	if err != nil {
+		return false, err
+	}
+
+	com.Stdout = nil
+	com.Stderr = nil
+
+	return com.Run() == nil, nil
+}
+
+// runSeeds runs executable files of the seed directory in lexical order, with the application
+// environment rewritten for the host, so that they can reach services.
+func runSeeds() error {
+	root, err := projectRoot()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(root, seedDir)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			slog.Debug("No seed directory", slog.String("dir", dir))
+
+			return nil
+		}
+
+		return fmt.Errorf("error listing seed scripts: %w", err)
+	}
+
+	env, err := hostEnv(DefaultEnvService)
+	if err != nil {
+		return err
+	}
+
+	environ := mergeEnv(os.Environ(), env)
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			slog.Debug("Skipping non-executable seed file", slog.String("file", entry.Name()))
+
+			continue
+		}
+
+		slog.Info("Running seed script", slog.String("script", entry.Name()))
+
+		// nosemgrep: gitlab.gosec.G204-1 // Seed scripts are the ones of the repository
+		com := exec.Command(filepath.Join(dir, entry.Name()))
+		com.Dir = root
+		com.Env = environ
+		com.Stdout = os.Stdout
+		com.Stderr = os.Stderr
+
+		err = com.Run()
+		if err != nil {
+			return fmt.Errorf("error running seed script %s: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,358 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// waitPollInterval is the interval services readiness is checked at.
+	waitPollInterval = time.Second
+	// probeImage is the image listening ports are read from, within network namespaces of services.
+	probeImage = "docker.io/alpine:3.22.1@sha256:4bcff63911fcb4448bd4fdacec207030997caf25e9bea4045fa6c8c44de311d1"
+	// tcpListenState is the state of listening sockets in procfs TCP tables.
+	tcpListenState = "0A"
+)
+
+var (
+	ErrServicesNotReady = errors.New("services not ready")
+	ErrServiceExited    = errors.New("service exited")
+)
+
+var (
+	// Detach is a flag to start services in the background.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Detach bool
+	// Wait is a flag to wait for services to be ready, implying [Detach].
+	//nolint:gochecknoglobals // Cobra flags are global
+	Wait bool
+	// WaitTimeout is a flag to set how long to wait for services to be ready.
+	//nolint:gochecknoglobals // Cobra flags are global
+	WaitTimeout time.Duration
+)
+
+var (
+	// portSchemes maps well-known container ports to the scheme of their URL, others being http.
+	//nolint:gochecknoglobals // Used as a const
+	portSchemes = map[int]string{
+		5432:  "postgresql",
+		6379:  "redis",
+		4317:  "grpc",
+		9200:  "https",
+		50000: "tcp",
+	}
+	// credentialEnvRegexp matches environment variables names holding credentials.
+	//nolint:gochecknoglobals // Used as a const
+	credentialEnvRegexp = regexp.MustCompile(`(?i)(user|password|passwd|_db$|database|token|secret)`)
+	// requirePassRegexp matches passwords passed as flags, as done for valkey and redis.
+	//nolint:gochecknoglobals // Used as a const
+	requirePassRegexp = regexp.MustCompile(`--requirepass\s+(\S+)`)
+)
+
+// startDetached starts services in the background, waits for them to be ready if wait is set,
+// then prints their URLs.
+func startDetached(wait bool) error {
+	err := runCompose("up", "--build", "--detach")
+	if err != nil {
+		return err
+	}
+
+	project, err := loadProject()
+	if err != nil {
+		return err
+	}
+
+	if wait {
+		err := waitReady(project, WaitTimeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	return printServices(project)
+}
+
+// waitReady polls services until all are ready, or timeout expires. A service is ready once its
+// containers run, are healthy if they define a healthcheck, and listen on their published TCP
+// ports, or once they exited successfully for one-shot services.
+func waitReady(project composeProject, timeout time.Duration) error {
+	slog.Info("Waiting for services to be ready", slog.Duration("timeout", timeout))
+
+	deadline := time.Now().Add(timeout)
+	notReady := map[string]string{}
+
+	for {
+		containers, err := listContainers()
+		if err != nil {
+			return err
+		}
+
+		clear(notReady)
+
+		for name, service := range project.Services {
+			reason, err := serviceReadiness(name, service, containers)
+			if err != nil {
+				return err
+			}
+
+			if reason != "" {
+				notReady[name] = reason
+			}
+		}
+
+		if len(notReady) == 0 {
+			slog.Info("All services are ready")
+
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			reasons := []string{}
+			for _, name := range slices.Sorted(maps.Keys(notReady)) {
+				reasons = append(reasons, name+" ("+notReady[name]+")")
+			}
+
+			return fmt.Errorf("after %s: %s: %w", timeout, strings.Join(reasons, ", "), ErrServicesNotReady)
+		}
+
+		slog.Debug("Services not ready yet", slog.Any("services", notReady))
+
+		time.Sleep(waitPollInterval)
+	}
+}
+
+// serviceReadiness returns why service is not ready, or an empty string if it is. Services whose
+// containers exited with an error make waiting pointless, and are reported as an error.
+func serviceReadiness(name string, service composeService, containers []composeContainer) (string, error) {
+	found := false
+
+	for _, container := range containers {
+		if container.Service != name {
+			continue
+		}
+
+		found = true
+
+		switch {
+		case container.State == "exited" && container.ExitCode != 0:
+			return "", fmt.Errorf("%s, exit code %d, see `kemutil dev logs %s`: %w", name, container.ExitCode, name, ErrServiceExited)
+		case container.State == "exited":
+			// One-shot services, such as migrations, are done
+			continue
+		case container.State != "running":
+			return container.State, nil
+		case service.hasHealthcheck() && container.Health != "healthy":
+			return firstNonEmpty(container.Health, "health unknown"), nil
+		}
+
+		reason := portsReadiness(service, container)
+		if reason != "" {
+			return reason, nil
+		}
+	}
+
+	if !found {
+		return "not created", nil
+	}
+
+	return "", nil
+}
+
+// portsReadiness returns why published TCP ports of service are not ready in container, or an
+// empty string if they are. Ports are checked from within the container network namespace, as
+// published ports accept connections on the host before the service listens.
+func portsReadiness(service composeService, container composeContainer) string {
+	ports := []composePort{}
+
+	for _, port := range service.Ports {
+		if port.Published != "" && (port.Protocol == "" || port.Protocol == "tcp") {
+			ports = append(ports, port)
+		}
+	}
+
+	if len(ports) == 0 {
+		return ""
+	}
+
+	listening, err := listeningPorts(container.Name)
+	if err != nil {
+		slog.Debug("Error probing listening ports", slog.String("container", container.Name), slog.String("error", err.Error()))
+
+		return "ports unknown"
+	}
+
+	for _, port := range ports {
+		if _, ok := listening[port.Target]; !ok {
+			return "port " + strconv.Itoa(port.Target) + " not listening"
+		}
+	}
+
+	return ""
+}
+
+// listeningPorts returns TCP ports listened on in the network namespace of container, read from
+// procfs by a [probeImage] container sharing it.
+func listeningPorts(container string) (map[int]struct{}, error) {
+	com, err := dockerCommand(
+		"run",
+		"--rm",
+		"--network",
+		"container:"+container,
+		probeImage,
+		"sh",
+		"-c",
+		"cat /proc/net/tcp /proc/net/tcp6 2>/dev/null; true",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pull progress and errors are only relevant in debug logs, as polling runs every second
+	com.Stderr = nil
+
+	out, err := com.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading listening ports: %w", err)
+	}
+
+	return parseListeningPorts(string(out)), nil
+}
+
+// parseListeningPorts returns ports of listening sockets of procfs TCP tables content.
+func parseListeningPorts(content string) map[int]struct{} {
+	ports := map[int]struct{}{}
+
+	for line := range strings.Lines(content) {
+		fields := strings.Fields(line)
+		// Fields are sl, local_address, rem_address, st, ...
+		if len(fields) < 4 || fields[3] != tcpListenState {
+			continue
+		}
+
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+
+		port, err := strconv.ParseInt(hexPort, 16, 32)
+		if err != nil {
+			continue
+		}
+
+		ports[int(port)] = struct{}{}
+	}
+
+	return ports
+}
+
+// localHost returns the host to reach a port published on hostIP from the host.
+func localHost(hostIP string) string {
+	if hostIP == "" || hostIP == "0.0.0.0" || hostIP == "::" {
+		return "localhost"
+	}
+
+	return hostIP
+}
+
+// serviceURL returns the URL of port published by service.
+func serviceURL(service composeService, port composePort) string {
+	scheme, ok := portSchemes[port.Target]
+	if !ok {
+		scheme = "http"
+	}
+
+	host := net.JoinHostPort(localHost(port.HostIP), port.Published)
+
+	if scheme == "postgresql" {
+		user, _ := service.env("POSTGRES_USER")
+		password, _ := service.env("POSTGRES_PASSWORD")
+		db, _ := service.env("POSTGRES_DB")
+
+		if user != "" {
+			return "postgresql://" + user + ":" + password + "@" + host + "/" + db
+		}
+	}
+
+	return scheme + "://" + host
+}
+
+// serviceCredentials returns credentials found in service environment, as KEY=VALUE.
+func serviceCredentials(service composeService) []string {
+	credentials := []string{}
+
+	for _, key := range slices.Sorted(maps.Keys(service.Environment)) {
+		value, ok := service.env(key)
+		if !ok {
+			continue
+		}
+
+		if match := requirePassRegexp.FindStringSubmatch(value); match != nil {
+			credentials = append(credentials, "password="+match[1])
+
+			continue
+		}
+
+		if credentialEnvRegexp.MatchString(key) {
+			credentials = append(credentials, key+"="+value)
+		}
+	}
+
+	return credentials
+}
+
+// printServices prints URLs of published ports of each service, along with credentials found
+// in their environment.
+func printServices(project composeProject) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "SERVICE\tURL\tCREDENTIALS")
+
+	for _, name := range slices.Sorted(maps.Keys(project.Services)) {
+		service := project.Services[name]
+
+		credentials := strings.Join(serviceCredentials(service), " ")
+
+		ports := slices.Clone(service.Ports)
+		slices.SortFunc(ports, func(a, b composePort) int {
+			return a.Target - b.Target
+		})
+
+		for _, port := range ports {
+			if port.Published == "" {
+				continue
+			}
+
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", name, serviceURL(service, port), credentials)
+		}
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing services table: %w", err)
+	}
+
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
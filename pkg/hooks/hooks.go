@@ -0,0 +1,332 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package hooks
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/config"
+	"github.com/kemadev/kemutil/internal/gitrepo"
+	"github.com/kemadev/kemutil/pkg/workflow"
+	"github.com/spf13/cobra"
+)
+
+const (
+	HookPreCommit = "pre-commit"
+	HookCommitMsg = "commit-msg"
+	HookPrePush   = "pre-push"
+)
+
+const (
+	// hookMarker identifies hooks installed by kemutil.
+	hookMarker = "# Managed by kemutil"
+	// hookBackupSuffix is appended to the path of hooks replaced by kemutil to back them up.
+	hookBackupSuffix = ".bak"
+)
+
+var (
+	ErrHookUnknown        = errors.New("unknown hook")
+	ErrHookExists         = errors.New("hook already exists and is not managed by kemutil")
+	ErrHookBackupExists   = errors.New("hook backup already exists")
+	ErrCommitMsgInvalid   = errors.New("commit message does not follow conventional commits format")
+	ErrCommitMsgFileUnset = errors.New("commit message file not provided")
+)
+
+// Hooks lists the hooks managed by kemutil.
+//
+//nolint:gochecknoglobals // Used as a const
+var Hooks = []string{HookPreCommit, HookCommitMsg, HookPrePush}
+
+// DefaultCommitTypes lists conventional commit types allowed when none are configured.
+//
+//nolint:gochecknoglobals // Used as a const
+var DefaultCommitTypes = []string{
+	"build",
+	"chore",
+	"ci",
+	"docs",
+	"feat",
+	"fix",
+	"perf",
+	"refactor",
+	"revert",
+	"style",
+	"test",
+}
+
+// Force is a flag to replace existing hooks not managed by kemutil, backing them up.
+//
+//nolint:gochecknoglobals // Cobra flags are global
+var Force bool
+
+// hooksDir returns the directory git reads hooks from, honoring core.hooksPath and worktrees.
+func hooksDir() (string, error) {
+	repoRoot, err := gitrepo.Root()
+	if err != nil {
+		return "", fmt.Errorf("error finding repository root: %w", err)
+	}
+
+	binary, err := exec.LookPath("git")
+	if err != nil {
+		return "", fmt.Errorf("git binary not found: %w", err)
+	}
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	com := exec.Command(binary, "rev-parse", "--git-path", "hooks")
+	com.Dir = repoRoot
+
+	out, err := com.Output()
+	if err != nil {
+		return "", fmt.Errorf("error getting hooks path: %w", err)
+	}
+
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoRoot, dir)
+	}
+
+	return dir, nil
+}
+
+func hookScript(hook string) string {
+	return `#!/usr/bin/env sh
+` + hookMarker + `, remove with ` + "`kemutil hooks uninstall`" + `
+exec kemutil hooks run ` + hook + ` "$@"
+`
+}
+
+// isManaged reports whether the hook at path was installed by kemutil.
+func isManaged(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("error reading hook: %w", err)
+	}
+
+	return strings.Contains(string(content), hookMarker), nil
+}
+
+// Install installs git hooks in the current repository, skipping disabled ones.
+func Install(_ *cobra.Command, _ []string) error {
+	slog.Info("Installing git hooks")
+
+	conf, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	dir, err := hooksDir()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+
+	for _, hook := range Hooks {
+		if slices.Contains(conf.Hooks.Disabled, hook) {
+			slog.Info("Hook is disabled, skipping", slog.String("hook", hook))
+
+			continue
+		}
+
+		path := filepath.Join(dir, hook)
+
+		_, err := os.Stat(path)
+		if err == nil {
+			managed, err := isManaged(path)
+			if err != nil {
+				return err
+			}
+
+			if !managed && !Force {
+				return fmt.Errorf("%s, use --force to replace it: %w", path, ErrHookExists)
+			}
+
+			if !managed {
+				err := backupHook(path)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		err = os.WriteFile(path, []byte(hookScript(hook)), 0o755)
+		if err != nil {
+			return fmt.Errorf("error writing hook: %w", err)
+		}
+
+		slog.Info("Installed hook", slog.String("hook", hook), slog.String("path", path))
+	}
+
+	return nil
+}
+
+// backupHook moves the hook at path to its backup path, restored on uninstall. Existing backups
+// are not overwritten.
+func backupHook(path string) error {
+	backup := path + hookBackupSuffix
+
+	_, err := os.Stat(backup)
+	if err == nil {
+		return fmt.Errorf("%s: %w", backup, ErrHookBackupExists)
+	}
+
+	err = os.Rename(path, backup)
+	if err != nil {
+		return fmt.Errorf("error backing up hook: %w", err)
+	}
+
+	slog.Info("Backed up existing hook", slog.String("path", backup))
+
+	return nil
+}
+
+// Uninstall removes git hooks installed by kemutil from the current repository.
+func Uninstall(_ *cobra.Command, _ []string) error {
+	slog.Info("Uninstalling git hooks")
+
+	dir, err := hooksDir()
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range Hooks {
+		path := filepath.Join(dir, hook)
+
+		_, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		managed, err := isManaged(path)
+		if err != nil {
+			return err
+		}
+
+		if !managed {
+			slog.Info("Hook is not managed by kemutil, leaving it", slog.String("hook", hook))
+
+			continue
+		}
+
+		err = os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("error removing hook: %w", err)
+		}
+
+		slog.Info("Uninstalled hook", slog.String("hook", hook))
+
+		err = os.Rename(path+hookBackupSuffix, path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("error restoring hook: %w", err)
+		}
+
+		slog.Info("Restored backed up hook", slog.String("hook", hook))
+	}
+
+	return nil
+}
+
+// Run runs the given hook, as invoked by git.
+func Run(cmd *cobra.Command, args []string) error {
+	hook := args[0]
+
+	slog.Debug("Running hook", slog.String("hook", hook))
+
+	if !slices.Contains(Hooks, hook) {
+		return fmt.Errorf("%q: %w", hook, ErrHookUnknown)
+	}
+
+	conf, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	if slices.Contains(conf.Hooks.Disabled, hook) {
+		slog.Info("Hook is disabled, skipping", slog.String("hook", hook))
+
+		return nil
+	}
+
+	switch hook {
+	case HookPreCommit:
+		return workflow.RunCi(cmd, workflow.ChangedOptions{Staged: true})
+	case HookCommitMsg:
+		if len(args) < 2 {
+			return ErrCommitMsgFileUnset
+		}
+
+		types := conf.Hooks.CommitTypes
+		if len(types) == 0 {
+			types = DefaultCommitTypes
+		}
+
+		return checkCommitMsg(args[1], types)
+	case HookPrePush:
+		return workflow.RunCi(cmd, workflow.ChangedOptions{})
+	}
+
+	return nil
+}
+
+// checkCommitMsg checks that the first line of the commit message in path follows conventional commits format.
+func checkCommitMsg(path string, types []string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading commit message: %w", err)
+	}
+
+	subject := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		subject = line
+
+		break
+	}
+
+	// Let git generated messages through, they get reworded or squashed anyway
+	for _, prefix := range []string{"Merge ", "Revert ", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(subject, prefix) {
+			return nil
+		}
+	}
+
+	quoted := make([]string, len(types))
+	for pos, t := range types {
+		quoted[pos] = regexp.QuoteMeta(t)
+	}
+
+	re := regexp.MustCompile(`^(` + strings.Join(quoted, "|") + `)(\([\w\-./ ]+\))?!?: \S.*$`)
+	if !re.MatchString(subject) {
+		return fmt.Errorf(
+			"%q, expected \"<type>[(scope)][!]: <description>\" with type one of %s: %w",
+			subject,
+			strings.Join(types, ", "),
+			ErrCommitMsgInvalid,
+		)
+	}
+
+	return nil
+}
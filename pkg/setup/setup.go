@@ -12,6 +12,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	maasExport "github.com/kemadev/infrastructure-components/deploy/infra/10-vars/export"
@@ -23,7 +24,9 @@ import (
 	"github.com/kemadev/infrastructure-components/pkg/private/constant/host"
 	"github.com/kemadev/infrastructure-components/pkg/private/constant/pulumi"
 	"github.com/kemadev/infrastructure-components/pkg/private/hardware/datacenter/datacenters"
+	"github.com/kemadev/kemutil/internal/redact"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 // Region to setup.
@@ -150,6 +153,8 @@ func MAASMachinesSSHKeys(_ *cobra.Command, _ []string) error {
 		filePath := ConfigPathBase + SSHSubPath + "maas-machines-" + Region + ".key"
 		if strings.Contains(refID, "Public") {
 			filePath += ".pub"
+		} else {
+			redact.Register(content)
 		}
 
 		dir := filepath.Dir(filePath)
@@ -215,6 +220,8 @@ func MAASControllersSSHKeys(_ *cobra.Command, _ []string) error {
 		filePath := ConfigPathBase + SSHSubPath + "maas-controllers-" + Region + ".key"
 		if strings.Contains(refID, "Public") {
 			filePath += ".pub"
+		} else {
+			redact.Register(content)
 		}
 
 		dir := filepath.Dir(filePath)
@@ -349,6 +356,11 @@ func KubeconfigAdmin(_ *cobra.Command, _ []string) error {
 		)
 	}
 
+	err = registerKubeconfigSecrets(content)
+	if err != nil {
+		return err
+	}
+
 	filePath := ConfigPathBase + KubernetesSubPath + Cluster + "-admin.yaml"
 
 	dir := filepath.Dir(filePath)
@@ -368,3 +380,51 @@ func KubeconfigAdmin(_ *cobra.Command, _ []string) error {
 
 	return nil
 }
+
+// kubeconfigSecretKeys are kubeconfig keys holding credentials.
+//
+//nolint:gochecknoglobals // Used as a const
+var kubeconfigSecretKeys = []string{
+	"certificate-authority-data",
+	"client-certificate-data",
+	"client-key-data",
+	"token",
+	"password",
+}
+
+// registerKubeconfigSecrets registers certificates, keys and tokens of kubeconfig for redaction,
+// leaving its structure, such as cluster names and addresses, readable in logs.
+func registerKubeconfigSecrets(kubeconfig string) error {
+	doc := map[string]any{}
+
+	err := yaml.Unmarshal([]byte(kubeconfig), &doc)
+	if err != nil {
+		return fmt.Errorf("error parsing kubeconfig: %w", err)
+	}
+
+	var walk func(value any)
+
+	walk = func(value any) {
+		switch typed := value.(type) {
+		case map[string]any:
+			for key, child := range typed {
+				secret, ok := child.(string)
+				if ok && slices.Contains(kubeconfigSecretKeys, key) {
+					redact.Register(secret)
+
+					continue
+				}
+
+				walk(child)
+			}
+		case []any:
+			for _, child := range typed {
+				walk(child)
+			}
+		}
+	}
+
+	walk(doc)
+
+	return nil
+}
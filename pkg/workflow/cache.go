@@ -0,0 +1,248 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kemadev/kemutil/internal/gitrepo"
+	"github.com/spf13/cobra"
+)
+
+// resultsCacheSubDir is the directory holding CI results, relative to kemutil cache directory.
+const resultsCacheSubDir = "ci-results"
+
+var (
+	// NoCache is a flag to disable CI results caching.
+	//nolint:gochecknoglobals // Cobra flags are global
+	NoCache bool
+	// MaxAge is a flag to prune only cache entries older than given duration.
+	//nolint:gochecknoglobals // Cobra flags are global
+	MaxAge time.Duration
+)
+
+// cachePath returns a path in kemutil cache directory.
+func cachePath(elem ...string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting cache directory: %w", err)
+	}
+
+	return filepath.Join(append([]string{cacheDir, "kemutil"}, elem...)...), nil
+}
+
+// cacheEntry is the content of a cached CI result.
+type cacheEntry struct {
+	Check string    `json:"check"`
+	Dir   string    `json:"dir"`
+	Time  time.Time `json:"time"`
+}
+
+// resultCache records successful check runs, keyed by the hash of their inputs.
+type resultCache struct {
+	dir     string
+	workdir string
+	imageID string
+	// extra holds inputs shared by all checks, such as container options
+	extra []string
+	// files maps repository-relative paths of tracked files to their content hash
+	files map[string]string
+	// order holds tracked files in a stable order
+	order []string
+}
+
+// newResultCache returns a cache for checks run with image. Extra inputs are added to all keys.
+func newResultCache(binary string, image string, extra ...string) (*resultCache, error) {
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	out, err := exec.Command(binary, "image", "inspect", "--format", "{{.Id}}", image).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting runner image ID, is it pulled?: %w", err)
+	}
+
+	dir, err := cachePath(resultsCacheSubDir)
+	if err != nil {
+		return nil, err
+	}
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("error getting current working directory: %w", err)
+	}
+
+	repoRoot, err := gitrepo.RootFromPath(workdir)
+	if err != nil {
+		return nil, fmt.Errorf("error finding repository root: %w", err)
+	}
+
+	files, err := gitrepo.TrackedFiles(repoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tracked files: %w", err)
+	}
+
+	hashes := make(map[string]string, len(files))
+
+	for _, file := range files {
+		hash, err := hashFile(filepath.Join(repoRoot, file))
+		if err != nil {
+			return nil, err
+		}
+
+		hashes[file] = hash
+	}
+
+	return &resultCache{
+		dir:     dir,
+		workdir: workdir,
+		imageID: strings.TrimSpace(string(out)),
+		extra:   extra,
+		files:   hashes,
+		order:   files,
+	}, nil
+}
+
+// resolvedEnvArgs returns docker args with variables forwarded from the host environment, given
+// as -e NAME, set to their host value, so that changing it changes cache keys.
+func resolvedEnvArgs(args []string) []string {
+	resolved := slices.Clone(args)
+
+	for pos := 1; pos < len(resolved); pos++ {
+		if resolved[pos-1] == "-e" && !strings.Contains(resolved[pos], "=") {
+			resolved[pos] += "=" + os.Getenv(resolved[pos])
+		}
+	}
+
+	return resolved
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("error opening file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", fmt.Errorf("error hashing file %s: %w", path, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// key returns the hash of all inputs of check.
+func (c *resultCache) key(check Check) string {
+	hash := sha256.New()
+
+	for _, input := range []string{
+		check.Name,
+		c.imageID,
+		c.workdir,
+		strconv.FormatBool(Fix && check.Fixable),
+		strconv.FormatBool(RunnerDebug),
+		strconv.FormatBool(ExportNetrc),
+	} {
+		fmt.Fprintf(hash, "%s\x00", input)
+	}
+
+	for _, input := range c.extra {
+		fmt.Fprintf(hash, "%s\x00", input)
+	}
+
+	for _, file := range c.order {
+		if check.tracks(file) {
+			fmt.Fprintf(hash, "%s\x00%s\x00", filepath.ToSlash(file), c.files[file])
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// hit reports whether a successful run with the same inputs is cached.
+func (c *resultCache) hit(key string) bool {
+	_, err := os.Stat(filepath.Join(c.dir, key))
+
+	return err == nil
+}
+
+// store records a successful run.
+func (c *resultCache) store(key string, check Check) error {
+	err := os.MkdirAll(c.dir, 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+
+	content, err := json.Marshal(cacheEntry{
+		Check: check.Name,
+		Dir:   c.workdir,
+		Time:  time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling cache entry: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(c.dir, key), content, 0o644)
+	if err != nil {
+		return fmt.Errorf("error writing cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// CachePrune removes cached CI results, optionally only those older than [MaxAge].
+func CachePrune(_ *cobra.Command, _ []string) error {
+	slog.Info("Pruning CI results cache")
+
+	dir, err := cachePath(resultsCacheSubDir)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("error reading cache directory: %w", err)
+	}
+
+	pruned := 0
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("error reading cache entry: %w", err)
+		}
+
+		if MaxAge > 0 && time.Since(info.ModTime()) < MaxAge {
+			continue
+		}
+
+		err = os.Remove(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("error removing cache entry: %w", err)
+		}
+
+		pruned++
+	}
+
+	slog.Info("Pruned CI results cache", slog.Int("entries", pruned))
+
+	return nil
+}
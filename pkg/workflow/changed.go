@@ -0,0 +1,136 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/gitrepo"
+)
+
+const (
+	// ChangedFilesEnvVarKey is the runner environment variable holding the path of the file
+	// listing files to check, one repository-relative path per line.
+	ChangedFilesEnvVarKey = "RUNNER_FILES_LIST"
+	// changedFilesContainerPath is the path the changed files list is mounted at in the runner container.
+	changedFilesContainerPath = "/run/kemutil/changed-files"
+)
+
+var ErrNoChangedFiles = errors.New("no changed files")
+
+var (
+	// Changed is a flag to check only files changed relative to the base branch.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Changed bool
+	// Base is a flag to set the branch changes are computed against.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Base string
+)
+
+// ChangedOptions select the files checked, in place of the --changed and --base flags.
+type ChangedOptions struct {
+	// Changed checks only files changed relative to Base.
+	Changed bool
+	// Base is the branch changes are computed against.
+	Base string
+	// Staged checks only files staged for commit.
+	Staged bool
+}
+
+// enabled reports whether checks are restricted to some files.
+func (o ChangedOptions) enabled() bool {
+	return o.Changed || o.Staged
+}
+
+// changedFiles returns the files changed according to opts, relative to the current directory.
+// Files outside of the current directory are omitted.
+func changedFiles(opts ChangedOptions) ([]string, error) {
+	workdir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("error getting current working directory: %w", err)
+	}
+
+	repoRoot, err := gitrepo.RootFromPath(workdir)
+	if err != nil {
+		return nil, fmt.Errorf("error finding repository root: %w", err)
+	}
+
+	var files []string
+
+	if opts.Staged {
+		files, err = gitrepo.StagedFiles(repoRoot)
+	} else {
+		files, err = gitrepo.ChangedFiles(repoRoot, opts.Base)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error computing changed files: %w", err)
+	}
+
+	relFiles := make([]string, 0, len(files))
+
+	for _, file := range files {
+		rel, err := filepath.Rel(workdir, filepath.Join(repoRoot, file))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		relFiles = append(relFiles, filepath.ToSlash(rel))
+	}
+
+	return relFiles, nil
+}
+
+// changedFilesArgs writes the list of changed files to the cache directory, and returns docker
+// arguments exposing it to the runner, along with the list. It returns [ErrNoChangedFiles] if
+// there is nothing to check.
+func changedFilesArgs(opts ChangedOptions) ([]string, []string, error) {
+	files, err := changedFiles(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, nil, ErrNoChangedFiles
+	}
+
+	slog.Debug("Found changed files", slog.Any("files", files))
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting current working directory: %w", err)
+	}
+
+	// One list per directory, so that concurrent runs in different repositories do not collide
+	sum := sha256.Sum256([]byte(workdir))
+
+	listPath, err := cachePath("changed-files", hex.EncodeToString(sum[:])[:16])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(listPath), 0o755)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating directory: %w", err)
+	}
+
+	err = os.WriteFile(listPath, []byte(strings.Join(files, "\n")+"\n"), 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error writing changed files list: %w", err)
+	}
+
+	return []string{
+		"-v",
+		listPath + ":" + changedFilesContainerPath + ":ro,Z",
+		"-e",
+		ChangedFilesEnvVarKey + "=" + changedFilesContainerPath,
+	}, files, nil
+}
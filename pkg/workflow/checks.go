@@ -0,0 +1,435 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrCheckSelectorUnknown = errors.New("unknown check selector")
+	ErrNoCheckSelected      = errors.New("no check selected")
+	ErrChecksFailed         = errors.New("some checks failed")
+)
+
+var (
+	// Only is a flag to run only the checks matching given selectors.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Only []string
+	// Skip is a flag to skip the checks matching given selectors.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Skip []string
+)
+
+// Check kinds.
+const (
+	KindLinter  = "linter"
+	KindScanner = "scanner"
+	KindTest    = "test"
+	KindBuild   = "build"
+	KindOther   = "other"
+)
+
+// Check is a runner command, run as part of CI or on its own.
+type Check struct {
+	// Name is the runner command running the check.
+	Name string
+	// Language is the language the check applies to, or "any".
+	Language string
+	// Kind is the kind of check, one of Kind* constants.
+	Kind string
+	// Description is a short description of the check.
+	Description string
+	// CI is true if the check is part of the `ci` runner command.
+	CI bool
+	// Fixable is true if the check supports fix mode.
+	Fixable bool
+	// Inputs are patterns of files the check reads, matched against base names, or against
+	// repository-relative paths for patterns containing a slash. Empty means all files.
+	Inputs []string
+}
+
+// KnownChecks is the catalog of runner commands, used to group checks and select them.
+//
+//nolint:gochecknoglobals // Used as a const
+var KnownChecks = []Check{
+	{
+		Name:        "go-lint",
+		Language:    "go",
+		Kind:        KindLinter,
+		Description: "Lint Go code",
+		CI:          true,
+		Fixable:     true,
+		Inputs:      []string{"*.go", "go.mod", "go.sum", ".golangci.y*ml"},
+	},
+	{
+		Name:        "go-mod-tidy",
+		Language:    "go",
+		Kind:        KindLinter,
+		Description: "Check go.mod files tidiness",
+		CI:          true,
+		Fixable:     true,
+		Inputs:      []string{"*.go", "go.mod", "go.sum"},
+	},
+	{
+		Name:        "go-mod-name",
+		Language:    "go",
+		Kind:        KindLinter,
+		Description: "Check go.mod modules names",
+		CI:          true,
+		Inputs:      []string{"go.mod"},
+	},
+	{
+		Name:        "go-test",
+		Language:    "go",
+		Kind:        KindTest,
+		Description: "Run Go unit tests",
+		CI:          true,
+	},
+	{
+		Name:        "go-cover",
+		Language:    "go",
+		Kind:        KindTest,
+		Description: "Check Go test coverage",
+		CI:          true,
+		Inputs:      []string{"*.go", "go.mod", "go.sum"},
+	},
+	{
+		Name:        "go-build",
+		Language:    "go",
+		Kind:        KindBuild,
+		Description: "Build Go binaries",
+		CI:          true,
+	},
+	{
+		Name:        "markdown",
+		Language:    "markdown",
+		Kind:        KindLinter,
+		Description: "Lint Markdown files",
+		CI:          true,
+		Fixable:     true,
+		Inputs:      []string{"*.md", ".markdownlint*"},
+	},
+	{
+		Name:        "shell",
+		Language:    "shell",
+		Kind:        KindLinter,
+		Description: "Lint shell scripts",
+		CI:          true,
+		Fixable:     true,
+		Inputs:      []string{"*.sh", "*.bash"},
+	},
+	{
+		Name:        "docker",
+		Language:    "docker",
+		Kind:        KindLinter,
+		Description: "Lint Dockerfiles",
+		CI:          true,
+		Inputs:      []string{"Dockerfile", "Dockerfile.*", "*.Dockerfile", ".hadolint.y*ml"},
+	},
+	{
+		Name:        "gha",
+		Language:    "gha",
+		Kind:        KindLinter,
+		Description: "Lint GitHub Actions workflows",
+		CI:          true,
+		Inputs:      []string{".github/workflows/*"},
+	},
+	{
+		Name:        "sast",
+		Language:    "any",
+		Kind:        KindScanner,
+		Description: "Run static application security testing",
+		CI:          true,
+	},
+	{
+		Name:        "secrets",
+		Language:    "any",
+		Kind:        KindScanner,
+		Description: "Scan for leaked secrets",
+		CI:          true,
+	},
+	{
+		Name:        "deps",
+		Language:    "any",
+		Kind:        KindScanner,
+		Description: "Scan dependencies for vulnerabilities",
+		CI:          true,
+	},
+	{
+		Name:        "deps-bump",
+		Language:    "any",
+		Kind:        KindOther,
+		Description: "Bump dependencies",
+	},
+	{
+		Name:        "branch-stale-check",
+		Language:    "any",
+		Kind:        KindOther,
+		Description: "Check branches staleness",
+	},
+	{
+		Name:        "pr-title-check",
+		Language:    "any",
+		Kind:        KindOther,
+		Description: "Check pull request title format",
+	},
+	{
+		Name:        "release",
+		Language:    "any",
+		Kind:        KindOther,
+		Description: "Release the project",
+	},
+}
+
+// matches reports whether selector designates check, by name, language, or kind.
+func (c Check) matches(selector string) bool {
+	return selector == c.Name || selector == c.Language || selector == c.Kind
+}
+
+// tracks reports whether file, relative to the repository root, is an input of the check.
+func (c Check) tracks(file string) bool {
+	if len(c.Inputs) == 0 {
+		return true
+	}
+
+	slashed := filepath.ToSlash(file)
+
+	for _, pattern := range c.Inputs {
+		target := path.Base(slashed)
+		if strings.Contains(pattern, "/") {
+			target = slashed
+		}
+
+		matched, err := path.Match(pattern, target)
+		if err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SelectChecks returns the CI checks matching only selectors, or all CI checks if only is empty,
+// minus the ones matching skip selectors.
+func SelectChecks(only []string, skip []string) ([]Check, error) {
+	ciChecks := slices.DeleteFunc(slices.Clone(KnownChecks), func(c Check) bool {
+		return !c.CI
+	})
+
+	errs := []error{}
+
+	for _, selector := range slices.Concat(only, skip) {
+		if !slices.ContainsFunc(ciChecks, func(c Check) bool { return c.matches(selector) }) {
+			errs = append(errs, fmt.Errorf("%q: %w", selector, ErrCheckSelectorUnknown))
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting checks, see `kemutil workflow list`: %w", err)
+	}
+
+	selected := slices.DeleteFunc(ciChecks, func(c Check) bool {
+		if len(only) > 0 && !slices.ContainsFunc(only, c.matches) {
+			return true
+		}
+
+		return slices.ContainsFunc(skip, c.matches)
+	})
+
+	if len(selected) == 0 {
+		return nil, ErrNoCheckSelected
+	}
+
+	return selected, nil
+}
+
+// runChecks runs each check in its own runner container, and returns an error if any failed.
+// If cache is not nil, checks with cached successful results are skipped.
+func runChecks(binary string, baseArgs []string, image string, checks []Check, cache *resultCache) error {
+	failed := []string{}
+	statuses := map[string]string{}
+
+	defer writeChecksStatus(statuses)
+
+	for _, check := range checks {
+		key := ""
+
+		if cache != nil {
+			key = cache.key(check)
+			if cache.hit(key) {
+				slog.Info("Check inputs did not change since last success, skipping", slog.String("check", check.Name))
+
+				statuses[check.Name] = statusCached
+
+				continue
+			}
+		}
+
+		args := slices.Concat(baseArgs, []string{image, check.Name})
+		if Fix && check.Fixable {
+			args = append(args, "--fix")
+		}
+
+		slog.Info("Running check", slog.String("check", check.Name))
+
+		err := runCommand(binary, args)
+		if err != nil {
+			slog.Error("Check failed", slog.String("check", check.Name), slog.String("error", err.Error()))
+
+			failed = append(failed, check.Name)
+			statuses[check.Name] = statusFail
+
+			continue
+		}
+
+		slog.Info("Check succeeded", slog.String("check", check.Name))
+
+		statuses[check.Name] = statusPass
+
+		if cache != nil {
+			err := cache.store(key, check)
+			if err != nil {
+				slog.Warn("Error caching check result", slog.String("check", check.Name), slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(failed, ", "), ErrChecksFailed)
+	}
+
+	return nil
+}
+
+// runnerCommands returns the commands advertised by the runner image help.
+func runnerCommands(binary string, image string) ([]string, map[string]string, error) {
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	out, err := exec.Command(binary, "run", "--rm", image, "--help").Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting runner help: %w", err)
+	}
+
+	names := []string{}
+	descriptions := map[string]string{}
+	inCommands := false
+
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "Available Commands:") {
+			inCommands = true
+
+			continue
+		}
+
+		if !inCommands {
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+
+		name, description, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if name == "help" || name == "completion" {
+			continue
+		}
+
+		names = append(names, name)
+		descriptions[name] = strings.TrimSpace(description)
+	}
+
+	return names, descriptions, nil
+}
+
+// List lists the checks provided by the runner image.
+func List(cmd *cobra.Command, _ []string) error {
+	slog.Debug("Running workflow list")
+
+	_, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	imageURL := getImageURL()
+	image := strings.TrimPrefix(imageURL.String(), "//")
+
+	binary, err := exec.LookPath("docker")
+	if err != nil {
+		return fmt.Errorf("docker binary not found: %w", err)
+	}
+
+	checks := slices.Clone(KnownChecks)
+
+	names, descriptions, err := runnerCommands(binary, image)
+	if err != nil || len(names) == 0 {
+		slog.Warn("Could not list runner image commands, listing known checks", slog.Any("error", err))
+	} else {
+		checks = slices.DeleteFunc(checks, func(c Check) bool {
+			return !slices.Contains(names, c.Name)
+		})
+
+		for _, name := range names {
+			if !slices.ContainsFunc(checks, func(c Check) bool { return c.Name == name }) {
+				checks = append(checks, Check{
+					Name:        name,
+					Language:    "any",
+					Kind:        KindOther,
+					Description: descriptions[name],
+				})
+			}
+		}
+	}
+
+	slices.SortStableFunc(checks, func(a, b Check) int {
+		return strings.Compare(a.Language, b.Language)
+	})
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "CHECK\tLANGUAGE\tKIND\tCI\tFIX\tDESCRIPTION")
+
+	for _, check := range checks {
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\t%s\n",
+			check.Name,
+			check.Language,
+			check.Kind,
+			yesNo(check.CI),
+			yesNo(check.Fixable),
+			check.Description,
+		)
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing checks list: %w", err)
+	}
+
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
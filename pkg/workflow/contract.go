@@ -0,0 +1,51 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// runnerContractsLabel is the runner image label listing, comma separated, the environment
+// variables the runner honours beyond its base contract, such as [ChangedFilesEnvVarKey].
+const runnerContractsLabel = "dev.kemadev.ci-cd.runner.contracts"
+
+var ErrRunnerContractUnsupported = errors.New("runner image does not support feature")
+
+// requireRunnerContract returns [ErrRunnerContractUnsupported] unless image declares supporting
+// the environment variable key in its [runnerContractsLabel] label, so that features relying on
+// the runner do not silently do nothing with images predating them.
+func requireRunnerContract(binary string, image string, key string) error {
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	out, err := exec.Command(
+		binary,
+		"image",
+		"inspect",
+		"--format",
+		`{{index .Config.Labels "`+runnerContractsLabel+`"}}`,
+		image,
+	).Output()
+	if err != nil {
+		return fmt.Errorf("error getting runner image labels: %w", err)
+	}
+
+	contracts := strings.Split(strings.TrimSpace(string(out)), ",")
+	if slices.ContainsFunc(contracts, func(contract string) bool {
+		return strings.TrimSpace(contract) == key
+	}) {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%s does not declare %s in its %s label, update it: %w",
+		image,
+		key,
+		runnerContractsLabel,
+		ErrRunnerContractUnsupported,
+	)
+}
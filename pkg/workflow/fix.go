@@ -0,0 +1,258 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/gitrepo"
+)
+
+// AsUser is a flag to run the runner container as the host user, instead of fixing files ownership
+// after fix runs, when no Go cache is mounted.
+//
+//nolint:gochecknoglobals // Cobra flags are global
+var AsUser bool
+
+// hostOwner returns the host user and group IDs, and false if they are not meaningful, as on
+// Windows, or if running as root.
+func hostOwner() (string, bool) {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid <= 0 || gid < 0 {
+		return "", false
+	}
+
+	return strconv.Itoa(uid) + ":" + strconv.Itoa(gid), true
+}
+
+// runsAsHostUser reports whether the runner container runs as the host user, as is the case if
+// [AsUser] is set, or if Go caches are mounted, so that they are not written to by several users.
+func runsAsHostUser() bool {
+	return AsUser || GoCache != GoCacheModeNone
+}
+
+// ownerArgs returns docker arguments running the runner container as the host user, according to
+// [runsAsHostUser].
+func ownerArgs() []string {
+	if !runsAsHostUser() {
+		return []string{}
+	}
+
+	owner, ok := hostOwner()
+	if !ok {
+		return []string{}
+	}
+
+	slog.Debug("Running runner container as host user", slog.String("owner", owner))
+
+	// Host user has no home in the runner image, tools need a writable one
+	return []string{"--user", owner, "-e", "HOME=/tmp"}
+}
+
+// fixSnapshot records the state of dirty files before a fix run, to tell which files it modified.
+type fixSnapshot struct {
+	workdir  string
+	repoRoot string
+	// hashes maps repository-relative paths of dirty files to their content hash, empty for
+	// deleted files
+	hashes map[string]string
+	// dirs holds repository-relative directories containing files not ignored by git, to tell
+	// directories created by the run
+	dirs map[string]struct{}
+}
+
+// snapshotFix records the state of dirty files of the repository containing the current directory.
+func snapshotFix() (*fixSnapshot, error) {
+	workdir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("error getting current working directory: %w", err)
+	}
+
+	repoRoot, err := gitrepo.RootFromPath(workdir)
+	if err != nil {
+		return nil, fmt.Errorf("error finding repository root: %w", err)
+	}
+
+	snapshot := &fixSnapshot{
+		workdir:  workdir,
+		repoRoot: repoRoot,
+		dirs:     map[string]struct{}{},
+	}
+
+	snapshot.hashes, err = snapshot.dirtyHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := gitrepo.TrackedFiles(repoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tracked files: %w", err)
+	}
+
+	for _, file := range files {
+		for dir := filepath.Dir(file); dir != "."; dir = filepath.Dir(dir) {
+			snapshot.dirs[dir] = struct{}{}
+		}
+	}
+
+	return snapshot, nil
+}
+
+// dirtyHashes returns the content hash of dirty files, keyed by repository-relative path.
+func (s *fixSnapshot) dirtyHashes() (map[string]string, error) {
+	files, err := gitrepo.DirtyFiles(s.repoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error getting repository status: %w", err)
+	}
+
+	hashes := make(map[string]string, len(files))
+
+	for _, file := range files {
+		hash, err := hashFile(filepath.Join(s.repoRoot, file))
+		if errors.Is(err, os.ErrNotExist) {
+			hash = ""
+		} else if err != nil {
+			return nil, err
+		}
+
+		hashes[file] = hash
+	}
+
+	return hashes, nil
+}
+
+// modified returns the repository-relative paths of files modified since the snapshot, that is
+// files whose status or content changed.
+func (s *fixSnapshot) modified() ([]string, error) {
+	after, err := s.dirtyHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+
+	for file, hash := range after {
+		before, wasDirty := s.hashes[file]
+		if !wasDirty || before != hash {
+			files = append(files, file)
+		}
+	}
+
+	// Files made clean again, such as a fix reverting a local change
+	for file := range s.hashes {
+		if _, ok := after[file]; !ok {
+			files = append(files, file)
+		}
+	}
+
+	slices.Sort(files)
+
+	return files, nil
+}
+
+// finishFix reports files modified since snapshot, and gives them back to the host user, unless
+// the runner container runs as the host user.
+func finishFix(binary string, image string, snapshot *fixSnapshot) error {
+	files, err := snapshot.modified()
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		slog.Info("Fix mode did not modify any file")
+
+		return nil
+	}
+
+	owner, ok := hostOwner()
+	if !runsAsHostUser() && ok {
+		err := chownFiles(binary, image, owner, snapshot, files)
+		if err != nil {
+			slog.Warn("Error giving modified files back to host user", slog.String("error", err.Error()))
+		}
+	}
+
+	fmt.Fprintf(os.Stdout, "Fix mode modified %d file(s):\n", len(files))
+
+	for _, file := range files {
+		fmt.Fprintf(os.Stdout, "  %s\n", file)
+	}
+
+	return nil
+}
+
+// created returns the repository-relative directories created since the snapshot holding files,
+// parents first.
+func (s *fixSnapshot) created(files []string) []string {
+	dirs := map[string]struct{}{}
+
+	for _, file := range files {
+		for dir := filepath.Dir(file); dir != "."; dir = filepath.Dir(dir) {
+			if _, ok := s.dirs[dir]; ok {
+				break
+			}
+
+			dirs[dir] = struct{}{}
+		}
+	}
+
+	return slices.Sorted(maps.Keys(dirs))
+}
+
+// chownFiles sets owner of files in the current workspace, along with directories created to hold
+// them, from a runner container as the host user may not be allowed to.
+func chownFiles(binary string, image string, owner string, snapshot *fixSnapshot, files []string) error {
+	ws, err := newWorkspace(snapshot.workdir)
+	if err != nil {
+		return err
+	}
+
+	paths := []string{}
+
+	for _, file := range slices.Concat(snapshot.created(files), files) {
+		hostPath := filepath.Join(snapshot.repoRoot, file)
+
+		containerPath, ok := ws.containerPath(hostPath)
+		if !ok {
+			continue
+		}
+
+		_, err = os.Lstat(hostPath)
+		if err != nil {
+			continue
+		}
+
+		paths = append(paths, containerPath)
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	slog.Debug("Giving modified files back to host user", slog.String("owner", owner), slog.Any("files", paths))
+
+	args := slices.Concat(
+		[]string{"run", "--rm"},
+		ws.args(),
+		[]string{"--entrypoint", "chown", image, "-h", owner},
+		paths,
+	)
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	out, err := exec.Command(binary, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error changing files owner: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFixSnapshotCreated(t *testing.T) {
+	t.Parallel()
+
+	snapshot := &fixSnapshot{
+		dirs: map[string]struct{}{
+			"pkg":                            {},
+			filepath.Join("pkg", "existing"): {},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{
+			name:  "root files",
+			files: []string{"go.mod"},
+			want:  nil,
+		},
+		{
+			name:  "existing directories",
+			files: []string{filepath.Join("pkg", "existing", "a.go"), filepath.Join("pkg", "b.go")},
+			want:  nil,
+		},
+		{
+			name:  "created directories",
+			files: []string{filepath.Join("pkg", "gen", "v1", "a.go"), filepath.Join("pkg", "gen", "b.go")},
+			want:  []string{filepath.Join("pkg", "gen"), filepath.Join("pkg", "gen", "v1")},
+		},
+		{
+			name:  "created top-level directory",
+			files: []string{filepath.Join("api", "a.go")},
+			want:  []string{"api"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := snapshot.created(test.files)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("created() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
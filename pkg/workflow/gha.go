@@ -0,0 +1,557 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/redact"
+	"gopkg.in/yaml.v3"
+)
+
+// GitHubWorkflowsDir is the directory containing GitHub Actions workflows, relative to the repository root.
+const GitHubWorkflowsDir = ".github/workflows"
+
+// checkoutAction is the action checking out the repository, which is a no-op as the repository is mounted.
+const checkoutAction = "actions/checkout"
+
+var (
+	ErrWorkflowNotFound      = errors.New("workflow file not found")
+	ErrJobNotFound           = errors.New("job not found")
+	ErrJobCycle              = errors.New("job dependency cycle")
+	ErrUnsupportedFeature    = errors.New("unsupported workflow feature")
+	ErrUnsupportedAction     = errors.New("unsupported action")
+	ErrUnsupportedExpression = errors.New("unsupported expression")
+)
+
+// stringList is a YAML value that is either a single string, or a list of strings.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = stringList{node.Value}
+
+		return nil
+	}
+
+	list := []string{}
+
+	err := node.Decode(&list)
+	if err != nil {
+		return fmt.Errorf("error decoding string list: %w", err)
+	}
+
+	*l = list
+
+	return nil
+}
+
+type ghaWorkflow struct {
+	Name     string            `yaml:"name"`
+	Env      map[string]string `yaml:"env"`
+	Defaults ghaDefaults       `yaml:"defaults"`
+	Jobs     map[string]ghaJob `yaml:"jobs"`
+}
+
+type ghaDefaults struct {
+	Run struct {
+		Shell            string `yaml:"shell"`
+		WorkingDirectory string `yaml:"working-directory"`
+	} `yaml:"run"`
+}
+
+type ghaJob struct {
+	Name      string            `yaml:"name"`
+	Needs     stringList        `yaml:"needs"`
+	If        string            `yaml:"if"`
+	Uses      string            `yaml:"uses"`
+	Env       map[string]string `yaml:"env"`
+	Defaults  ghaDefaults       `yaml:"defaults"`
+	Container ghaContainer      `yaml:"container"`
+	Services  map[string]any    `yaml:"services"`
+	Strategy  ghaStrategy       `yaml:"strategy"`
+	Steps     []ghaStep         `yaml:"steps"`
+}
+
+// ghaContainer is a job container, either given as an image name or as a mapping.
+type ghaContainer struct {
+	Image   string            `yaml:"image"`
+	Env     map[string]string `yaml:"env"`
+	Options string            `yaml:"options"`
+}
+
+func (c *ghaContainer) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Image = node.Value
+
+		return nil
+	}
+
+	type plain ghaContainer
+
+	err := node.Decode((*plain)(c))
+	if err != nil {
+		return fmt.Errorf("error decoding container: %w", err)
+	}
+
+	return nil
+}
+
+type ghaStrategy struct {
+	Matrix   yaml.Node `yaml:"matrix"`
+	FailFast *bool     `yaml:"fail-fast"`
+}
+
+type ghaStep struct {
+	ID               string            `yaml:"id"`
+	Name             string            `yaml:"name"`
+	If               string            `yaml:"if"`
+	Uses             string            `yaml:"uses"`
+	Run              string            `yaml:"run"`
+	Shell            string            `yaml:"shell"`
+	WorkingDirectory string            `yaml:"working-directory"`
+	Env              map[string]string `yaml:"env"`
+}
+
+func (s ghaStep) displayName(pos int) string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.ID != "":
+		return s.ID
+	case s.Uses != "":
+		return s.Uses
+	default:
+		return fmt.Sprintf("step %d", pos+1)
+	}
+}
+
+// findWorkflowFile returns the path of the workflow designated by name, which is either
+// a path, or a file name in [GitHubWorkflowsDir], with or without extension.
+func findWorkflowFile(name string, repoRoot string) (string, error) {
+	candidates := []string{name}
+
+	for _, ext := range []string{"", ".yaml", ".yml"} {
+		candidates = append(candidates, filepath.Join(repoRoot, GitHubWorkflowsDir, name+ext))
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("%q: %w", name, ErrWorkflowNotFound)
+}
+
+func parseWorkflow(path string) (ghaWorkflow, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ghaWorkflow{}, fmt.Errorf("error reading workflow file: %w", err)
+	}
+
+	wf := ghaWorkflow{}
+
+	err = yaml.Unmarshal(content, &wf)
+	if err != nil {
+		return ghaWorkflow{}, fmt.Errorf("error parsing workflow file %s: %w", path, err)
+	}
+
+	return wf, nil
+}
+
+// jobOrder returns the jobs to run in dependency order. If target is not empty, only
+// target and its transitive dependencies are returned.
+func jobOrder(jobs map[string]ghaJob, target string) ([]string, error) {
+	roots := slices.Sorted(maps.Keys(jobs))
+	if target != "" {
+		if _, ok := jobs[target]; !ok {
+			return nil, fmt.Errorf("%q: %w", target, ErrJobNotFound)
+		}
+
+		roots = []string{target}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := map[string]int{}
+	order := []string{}
+
+	var visit func(id string, path []string) error
+
+	visit = func(id string, path []string) error {
+		switch state[id] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%s: %w", strings.Join(append(path, id), " -> "), ErrJobCycle)
+		}
+
+		job, ok := jobs[id]
+		if !ok {
+			return fmt.Errorf("%q, needed by %q: %w", id, path[len(path)-1], ErrJobNotFound)
+		}
+
+		state[id] = visiting
+
+		for _, need := range slices.Sorted(slices.Values(job.Needs)) {
+			err := visit(need, append(path, id))
+			if err != nil {
+				return err
+			}
+		}
+
+		state[id] = visited
+		order = append(order, id)
+
+		return nil
+	}
+
+	for _, id := range roots {
+		err := visit(id, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// expandMatrix returns all combinations of the job matrix, an empty combination being
+// returned for jobs without matrix.
+func expandMatrix(strategy ghaStrategy) ([]map[string]any, error) {
+	if strategy.Matrix.Kind == 0 {
+		return []map[string]any{{}}, nil
+	}
+
+	if strategy.Matrix.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("matrix must be a mapping, expressions are not supported: %w", ErrUnsupportedFeature)
+	}
+
+	raw := map[string]any{}
+
+	err := strategy.Matrix.Decode(&raw)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding matrix: %w", err)
+	}
+
+	include, err := matrixEntries(raw["include"])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding matrix include: %w", err)
+	}
+
+	exclude, err := matrixEntries(raw["exclude"])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding matrix exclude: %w", err)
+	}
+
+	delete(raw, "include")
+	delete(raw, "exclude")
+
+	keys := slices.Sorted(maps.Keys(raw))
+	combinations := []map[string]any{}
+
+	if len(keys) > 0 {
+		combinations = []map[string]any{{}}
+	}
+
+	for _, key := range keys {
+		values, ok := raw[key].([]any)
+		if !ok {
+			return nil, fmt.Errorf("matrix key %q must be a list, expressions are not supported: %w", key, ErrUnsupportedFeature)
+		}
+
+		next := []map[string]any{}
+
+		for _, combination := range combinations {
+			for _, value := range values {
+				extended := maps.Clone(combination)
+				extended[key] = value
+				next = append(next, extended)
+			}
+		}
+
+		combinations = next
+	}
+
+	combinations = slices.DeleteFunc(combinations, func(combination map[string]any) bool {
+		return slices.ContainsFunc(exclude, func(entry map[string]any) bool {
+			return matrixMatches(combination, entry)
+		})
+	})
+
+	// Following GitHub rules, an include entry is merged into every original combination it does
+	// not overwrite an original value of, values added by previous entries being overwritable. An
+	// entry merged into no combination is added as a new combination, which later entries are not
+	// merged into.
+	original := len(combinations)
+
+	for _, entry := range include {
+		merged := false
+
+		for _, combination := range combinations[:original] {
+			if !matrixExtends(combination, entry, keys) {
+				continue
+			}
+
+			maps.Copy(combination, entry)
+
+			merged = true
+		}
+
+		if !merged {
+			combinations = append(combinations, maps.Clone(entry))
+		}
+	}
+
+	if len(combinations) == 0 {
+		return []map[string]any{{}}, nil
+	}
+
+	return combinations, nil
+}
+
+// matrixExtends reports whether entry can be merged into combination without overwriting values
+// of keys of the original matrix.
+func matrixExtends(combination map[string]any, entry map[string]any, keys []string) bool {
+	for key, value := range entry {
+		if slices.Contains(keys, key) && fmt.Sprint(combination[key]) != fmt.Sprint(value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matrixEntries(raw any) ([]map[string]any, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a list: %w", ErrUnsupportedFeature)
+	}
+
+	entries := make([]map[string]any, 0, len(list))
+
+	for _, item := range list {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected a list of mappings: %w", ErrUnsupportedFeature)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// matrixMatches reports whether combination has all values of entry.
+func matrixMatches(combination map[string]any, entry map[string]any) bool {
+	for key, value := range entry {
+		if fmt.Sprint(combination[key]) != fmt.Sprint(value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matrixDisplayName(combination map[string]any) string {
+	if len(combination) == 0 {
+		return ""
+	}
+
+	parts := []string{}
+	for _, key := range slices.Sorted(maps.Keys(combination)) {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, combination[key]))
+	}
+
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+var (
+	//nolint:gochecknoglobals // Used as a const
+	expressionRegexp = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+	//nolint:gochecknoglobals // Used as a const
+	propertyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+// exprContext holds values available to workflow expressions.
+type exprContext struct {
+	matrix map[string]any
+	env    map[string]string
+	github map[string]string
+}
+
+// eval evaluates a single expression. Only context property lookups are supported.
+// Secrets are read from the host environment, and registered for redaction.
+func (c exprContext) eval(expr string) (string, error) {
+	scope, property, found := strings.Cut(expr, ".")
+	if !found || !propertyRegexp.MatchString(property) {
+		return "", fmt.Errorf("%q: %w", expr, ErrUnsupportedExpression)
+	}
+
+	switch scope {
+	case "matrix":
+		value, ok := c.matrix[property]
+		if !ok {
+			return "", nil
+		}
+
+		return fmt.Sprint(value), nil
+	case "env":
+		return c.env[property], nil
+	case "secrets":
+		value := os.Getenv(property)
+		if value != "" {
+			redact.Register(value)
+		}
+
+		return value, nil
+	case "github":
+		return c.github[property], nil
+	case "runner":
+		if property == "os" {
+			return "Linux", nil
+		}
+	}
+
+	return "", fmt.Errorf("%q: %w", expr, ErrUnsupportedExpression)
+}
+
+// expand replaces all expressions in str by their value.
+func (c exprContext) expand(str string) (string, error) {
+	errs := []error{}
+
+	expanded := expressionRegexp.ReplaceAllStringFunc(str, func(match string) string {
+		value, err := c.eval(expressionRegexp.FindStringSubmatch(match)[1])
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		return value
+	})
+
+	return expanded, errors.Join(errs...)
+}
+
+// stepCondition is the evaluated form of a step `if` condition.
+type stepCondition int
+
+const (
+	conditionSuccess stepCondition = iota
+	conditionAlways
+	conditionFailure
+)
+
+// parseCondition parses an `if` condition. Only status check functions are supported.
+func parseCondition(cond string) (stepCondition, error) {
+	cond = strings.TrimSpace(cond)
+	if match := expressionRegexp.FindStringSubmatch(cond); match != nil && match[0] == cond {
+		cond = match[1]
+	}
+
+	switch cond {
+	case "", "success()":
+		return conditionSuccess, nil
+	case "always()", "!cancelled()":
+		return conditionAlways, nil
+	case "failure()":
+		return conditionFailure, nil
+	default:
+		return conditionSuccess, fmt.Errorf("condition %q: %w", cond, ErrUnsupportedExpression)
+	}
+}
+
+// validateJob returns all reasons preventing job from running locally.
+func validateJob(id string, job ghaJob, defaults ghaDefaults) error {
+	errs := []error{}
+
+	if job.Uses != "" {
+		errs = append(errs, fmt.Errorf("job %q calls reusable workflow %q: %w", id, job.Uses, ErrUnsupportedFeature))
+	}
+
+	if len(job.Services) > 0 {
+		errs = append(errs, fmt.Errorf("job %q uses services: %w", id, ErrUnsupportedFeature))
+	}
+
+	_, err := parseCondition(job.If)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("job %q: %w", id, err))
+	}
+
+	_, err = expandMatrix(job.Strategy)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("job %q: %w", id, err))
+	}
+
+	for pos, step := range job.Steps {
+		name := step.displayName(pos)
+
+		switch {
+		case step.Uses != "" && !strings.HasPrefix(step.Uses, checkoutAction+"@"):
+			errs = append(errs, fmt.Errorf(
+				"job %q, step %q uses action %q, only `run` steps and %s are supported: %w",
+				id,
+				name,
+				step.Uses,
+				checkoutAction,
+				ErrUnsupportedAction,
+			))
+		case step.Uses == "" && step.Run == "":
+			errs = append(errs, fmt.Errorf("job %q, step %q has neither `run` nor `uses`: %w", id, name, ErrUnsupportedFeature))
+		}
+
+		_, err := shellArgs(firstNonEmpty(step.Shell, job.Defaults.Run.Shell, defaults.Run.Shell))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("job %q, step %q: %w", id, name, err))
+		}
+
+		_, err = parseCondition(step.If)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("job %q, step %q: %w", id, name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// shellArgs returns the command running a script with shell, the script being appended as last argument.
+// As on GitHub hosted runners, bash is used by default, with pipefail only if set explicitly.
+func shellArgs(shell string) ([]string, error) {
+	switch shell {
+	case "":
+		return []string{"bash", "-e", "-c"}, nil
+	case "sh":
+		return []string{"sh", "-e", "-c"}, nil
+	case "bash":
+		return []string{"bash", "--noprofile", "--norc", "-e", "-o", "pipefail", "-c"}, nil
+	default:
+		return nil, fmt.Errorf("shell %q: %w", shell, ErrUnsupportedFeature)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExpandMatrix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		matrix string
+		want   []map[string]any
+	}{
+		{
+			name:   "no matrix",
+			matrix: "",
+			want:   []map[string]any{{}},
+		},
+		{
+			name:   "product",
+			matrix: "{os: [linux, darwin], go: [1, 2]}",
+			want: []map[string]any{
+				{"go": 1, "os": "linux"},
+				{"go": 1, "os": "darwin"},
+				{"go": 2, "os": "linux"},
+				{"go": 2, "os": "darwin"},
+			},
+		},
+		{
+			name:   "exclude",
+			matrix: "{os: [linux, darwin], go: [1, 2], exclude: [{os: darwin, go: 1}]}",
+			want: []map[string]any{
+				{"go": 1, "os": "linux"},
+				{"go": 2, "os": "linux"},
+				{"go": 2, "os": "darwin"},
+			},
+		},
+		{
+			name:   "include only",
+			matrix: "{include: [{os: linux}, {os: darwin}]}",
+			want: []map[string]any{
+				{"os": "linux"},
+				{"os": "darwin"},
+			},
+		},
+		{
+			name:   "include not matching original value",
+			matrix: "{os: [linux], include: [{os: windows, arch: arm64}]}",
+			want: []map[string]any{
+				{"os": "linux"},
+				{"os": "windows", "arch": "arm64"},
+			},
+		},
+		{
+			// Example of the GitHub documentation on expanding or adding matrix configurations
+			name: "include rules",
+			matrix: `
+fruit: [apple, pear]
+animal: [cat, dog]
+include:
+  - color: green
+  - color: pink
+    animal: cat
+  - fruit: apple
+    shape: circle
+  - fruit: banana
+  - fruit: banana
+    animal: cat
+`,
+			want: []map[string]any{
+				{"fruit": "apple", "animal": "cat", "color": "pink", "shape": "circle"},
+				{"fruit": "pear", "animal": "cat", "color": "pink"},
+				{"fruit": "apple", "animal": "dog", "color": "green", "shape": "circle"},
+				{"fruit": "pear", "animal": "dog", "color": "green"},
+				{"fruit": "banana"},
+				{"fruit": "banana", "animal": "cat"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			strategy := ghaStrategy{}
+
+			if test.matrix != "" {
+				err := yaml.Unmarshal([]byte(test.matrix), &strategy.Matrix)
+				if err != nil {
+					t.Fatalf("error parsing matrix: %v", err)
+				}
+
+				// Unmarshalling into a node yields a document node
+				strategy.Matrix = *strategy.Matrix.Content[0]
+			}
+
+			got, err := expandMatrix(strategy)
+			if err != nil {
+				t.Fatalf("expandMatrix() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expandMatrix() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExpandMatrixExpression(t *testing.T) {
+	t.Parallel()
+
+	strategy := ghaStrategy{}
+
+	err := yaml.Unmarshal([]byte("matrix: ${{ fromJSON(needs.setup.outputs.matrix) }}"), &strategy)
+	if err != nil {
+		t.Fatalf("error parsing strategy: %v", err)
+	}
+
+	_, err = expandMatrix(strategy)
+	if err == nil {
+		t.Error("expandMatrix() error = nil, want unsupported feature error")
+	}
+}
+
+func TestShellArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{shell: "", want: []string{"bash", "-e", "-c"}},
+		{shell: "bash", want: []string{"bash", "--noprofile", "--norc", "-e", "-o", "pipefail", "-c"}},
+		{shell: "sh", want: []string{"sh", "-e", "-c"}},
+	}
+
+	for _, test := range tests {
+		got, err := shellArgs(test.shell)
+		if err != nil {
+			t.Fatalf("shellArgs(%q) error = %v", test.shell, err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("shellArgs(%q) = %v, want %v", test.shell, got, test.want)
+		}
+	}
+
+	_, err := shellArgs("pwsh")
+	if err == nil {
+		t.Error(`shellArgs("pwsh") error = nil, want unsupported feature error`)
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kemadev/kemutil/internal/units"
+	"github.com/spf13/cobra"
+)
+
+// Go cache modes.
+const (
+	// GoCacheModeVolume mounts docker named volumes managed by kemutil.
+	GoCacheModeVolume = "volume"
+	// GoCacheModeHost mounts the host GOMODCACHE and GOCACHE.
+	GoCacheModeHost = "host"
+	// GoCacheModeNone mounts no cache.
+	GoCacheModeNone = "none"
+)
+
+const (
+	goModCacheVolume          = "kemutil-gomodcache"
+	goBuildCacheVolume        = "kemutil-gocache"
+	goModCacheContainerPath   = "/run/kemutil/gomodcache"
+	goBuildCacheContainerPath = "/run/kemutil/gocache"
+	// volumeLabel marks docker volumes managed by kemutil.
+	volumeLabel = "dev.kemadev.kemutil"
+)
+
+var ErrGoCacheModeInvalid = errors.New("invalid Go cache mode")
+
+// GoCache is a flag to set how Go caches are provided to the runner, one of GoCacheMode* constants.
+//
+//nolint:gochecknoglobals // Cobra flags are global
+var GoCache string
+
+// goCache is a Go cache mounted into the runner.
+type goCache struct {
+	// EnvVar is the Go environment variable pointing to the cache.
+	EnvVar string
+	// Volume is the name of the docker volume holding the cache in volume mode.
+	Volume string
+	// ContainerPath is the path the cache is mounted at in the runner container.
+	ContainerPath string
+}
+
+//nolint:gochecknoglobals // Used as a const
+var goCaches = []goCache{
+	{EnvVar: "GOMODCACHE", Volume: goModCacheVolume, ContainerPath: goModCacheContainerPath},
+	{EnvVar: "GOCACHE", Volume: goBuildCacheVolume, ContainerPath: goBuildCacheContainerPath},
+}
+
+// hostGoEnv returns the value of a Go environment variable on the host.
+func hostGoEnv(key string) (string, error) {
+	binary, err := exec.LookPath("go")
+	if err != nil {
+		return "", fmt.Errorf("go binary not found: %w", err)
+	}
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	out, err := exec.Command(binary, "env", key).Output()
+	if err != nil {
+		return "", fmt.Errorf("error getting go env %s: %w", key, err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// goCacheArgs returns docker arguments mounting Go caches into the runner, according to [GoCache].
+func goCacheArgs() ([]string, error) {
+	args := []string{}
+
+	switch GoCache {
+	case GoCacheModeNone:
+		return args, nil
+	case GoCacheModeVolume, "":
+		binary, err := exec.LookPath("docker")
+		if err != nil {
+			return nil, fmt.Errorf("docker binary not found: %w", err)
+		}
+
+		err = createCacheVolumes(binary)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cache := range goCaches {
+			args = append(args, "-v", cache.Volume+":"+cache.ContainerPath, "-e", cache.EnvVar+"="+cache.ContainerPath)
+		}
+	case GoCacheModeHost:
+		// The runner container runs as the host user, see ownerArgs, so that it does not write
+		// root-owned files into host caches
+		for _, cache := range goCaches {
+			hostPath, err := hostGoEnv(cache.EnvVar)
+			if err != nil {
+				return nil, err
+			}
+
+			err = os.MkdirAll(hostPath, 0o755)
+			if err != nil {
+				return nil, fmt.Errorf("error creating directory: %w", err)
+			}
+
+			args = append(args, "-v", hostPath+":"+cache.ContainerPath+":z", "-e", cache.EnvVar+"="+cache.ContainerPath)
+		}
+	default:
+		return nil, fmt.Errorf(
+			"%q, expected one of %s, %s, %s: %w",
+			GoCache,
+			GoCacheModeVolume,
+			GoCacheModeHost,
+			GoCacheModeNone,
+			ErrGoCacheModeInvalid,
+		)
+	}
+
+	slog.Debug("Mounting Go caches", slog.String("mode", GoCache), slog.Any("args", args))
+
+	return args, nil
+}
+
+// createCacheVolumes creates Go cache volumes that do not exist yet, labelled as managed by
+// kemutil, and gives them to the host user the runner container runs as. Existing volumes are
+// listed at once, and left as they are.
+func createCacheVolumes(binary string) error {
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	out, err := exec.Command(binary, "volume", "ls", "--quiet", "--filter", "label="+volumeLabel).Output()
+	if err != nil {
+		return fmt.Errorf("error listing volumes: %w", err)
+	}
+
+	existing := strings.Fields(string(out))
+
+	for _, cache := range goCaches {
+		if slices.Contains(existing, cache.Volume) {
+			continue
+		}
+
+		slog.Debug("Creating Go cache volume", slog.String("volume", cache.Volume))
+
+		// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+		out, err := exec.Command(binary, "volume", "create", "--label", volumeLabel+"=go-cache", cache.Volume).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("error creating volume %s: %s: %w", cache.Volume, strings.TrimSpace(string(out)), err)
+		}
+
+		err = chownVolume(binary, cache.Volume)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chownVolume gives a newly created volume to the host user, so that runner containers, run as
+// the host user, can write to it.
+func chownVolume(binary string, volume string) error {
+	owner, ok := hostOwner()
+	if !ok {
+		return nil
+	}
+
+	imageURL := getImageURL()
+	image := strings.TrimPrefix(imageURL.String(), "//")
+
+	err := ensureImage(binary, image)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("Giving volume to host user", slog.String("volume", volume), slog.String("owner", owner))
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	out, err := exec.Command(
+		binary,
+		"run",
+		"--rm",
+		"--user",
+		"0:0",
+		"-v",
+		volume+":/cache",
+		"--entrypoint",
+		"chown",
+		image,
+		owner,
+		"/cache",
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error giving volume %s to host user: %s: %w", volume, strings.TrimSpace(string(out)), err)
+	}
+
+	return nil
+}
+
+// volumeSize returns the human readable size of a docker volume, measured from the runner image.
+func volumeSize(binary string, image string, volume string) (string, error) {
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	out, err := exec.Command(
+		binary,
+		"run",
+		"--rm",
+		"-v",
+		volume+":/cache:ro",
+		"--entrypoint",
+		"du",
+		image,
+		"-sh",
+		"/cache",
+	).Output()
+	if err != nil {
+		return "", fmt.Errorf("error measuring volume %s: %w", volume, err)
+	}
+
+	size, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\t")
+
+	return size, nil
+}
+
+// dirSize returns the human readable size of a directory.
+func dirSize(dir string) (string, error) {
+	var size int64
+
+	err := filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		size += info.Size()
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error measuring directory %s: %w", dir, err)
+	}
+
+	return units.HumanSize(size), nil
+}
+
+// CacheInfo prints the location and size of workflow caches.
+func CacheInfo(cmd *cobra.Command, _ []string) error {
+	slog.Debug("Running workflow cache info")
+
+	_, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	binary, err := exec.LookPath("docker")
+	if err != nil {
+		return fmt.Errorf("docker binary not found: %w", err)
+	}
+
+	imageURL := getImageURL()
+	image := strings.TrimPrefix(imageURL.String(), "//")
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "CACHE\tMODE\tLOCATION\tSIZE")
+
+	for _, cache := range goCaches {
+		size := "absent"
+
+		// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+		err := exec.Command(binary, "volume", "inspect", cache.Volume).Run()
+		if err == nil {
+			size, err = volumeSize(binary, image, cache.Volume)
+			if err != nil {
+				slog.Warn("Error measuring volume", slog.String("volume", cache.Volume), slog.String("error", err.Error()))
+
+				size = "unknown"
+			}
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", cache.EnvVar, GoCacheModeVolume, cache.Volume, size)
+
+		hostPath, err := hostGoEnv(cache.EnvVar)
+		if err != nil {
+			slog.Debug("Host Go cache unavailable", slog.String("cache", cache.EnvVar), slog.String("error", err.Error()))
+
+			continue
+		}
+
+		size, err = dirSize(hostPath)
+		if err != nil {
+			size = "absent"
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", cache.EnvVar, GoCacheModeHost, hostPath, size)
+	}
+
+	resultsDir, err := cachePath(resultsCacheSubDir)
+	if err != nil {
+		return err
+	}
+
+	size, err := dirSize(resultsDir)
+	if err != nil {
+		size = "absent"
+	}
+
+	fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", "CI results", "-", resultsDir, size)
+
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing cache info: %w", err)
+	}
+
+	return nil
+}
+
+// CacheClear removes Go cache volumes managed by kemutil. Host caches are left untouched.
+func CacheClear(_ *cobra.Command, _ []string) error {
+	slog.Info("Clearing Go cache volumes")
+
+	binary, err := exec.LookPath("docker")
+	if err != nil {
+		return fmt.Errorf("docker binary not found: %w", err)
+	}
+
+	for _, cache := range goCaches {
+		// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+		err := exec.Command(binary, "volume", "inspect", cache.Volume).Run()
+		if err != nil {
+			slog.Debug("Volume does not exist, skipping", slog.String("volume", cache.Volume))
+
+			continue
+		}
+
+		// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+		out, err := exec.Command(binary, "volume", "rm", cache.Volume).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("error removing volume %s: %s: %w", cache.Volume, strings.TrimSpace(string(out)), err)
+		}
+
+		slog.Info("Removed volume", slog.String("volume", cache.Volume))
+	}
+
+	return nil
+}
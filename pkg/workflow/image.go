@@ -0,0 +1,153 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var ErrImageUnavailable = errors.New("runner image unavailable")
+
+// Registry is a flag to pull the runner image from a registry mirror, as host[/prefix], replacing
+// the default registry.
+//
+//nolint:gochecknoglobals // Cobra flags are global
+var Registry string
+
+// applyRegistry returns imageURL pulled from [Registry] if set.
+func applyRegistry(imageURL url.URL) url.URL {
+	if Registry == "" {
+		return imageURL
+	}
+
+	host, prefix, _ := strings.Cut(strings.TrimSuffix(Registry, "/"), "/")
+	imageURL.Host = host
+	imageURL.Path = path.Join(prefix, imageURL.Path)
+
+	return imageURL
+}
+
+// ensureImage makes sure image is present locally, pulling it if needed.
+func ensureImage(binary string, image string) error {
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	err := exec.Command(binary, "image", "inspect", image).Run()
+	if err == nil {
+		return nil
+	}
+
+	slog.Info("Runner image not present locally, pulling it", slog.String("image", image))
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	com := exec.Command(binary, "pull", image)
+	com.Stdout = os.Stderr
+	com.Stderr = os.Stderr
+
+	err = com.Run()
+	if err != nil {
+		return fmt.Errorf(
+			"%s is neither present locally nor pullable, check network access and registry credentials, "+
+				"set a registry mirror with --registry, or load a saved image with `kemutil workflow image load`: %w",
+			image,
+			errors.Join(ErrImageUnavailable, err),
+		)
+	}
+
+	return nil
+}
+
+// ImageSave saves the runner image to a tarball, gzip compressed if its name ends with .gz.
+func ImageSave(cmd *cobra.Command, args []string) error {
+	slog.Debug("Running workflow image save")
+
+	_, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	binary, err := exec.LookPath("docker")
+	if err != nil {
+		return fmt.Errorf("docker binary not found: %w", err)
+	}
+
+	imageURL := getImageURL()
+	image := strings.TrimPrefix(imageURL.String(), "//")
+
+	err = ensureImage(binary, image)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(args[0])
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+	defer file.Close()
+
+	var (
+		out      io.Writer = file
+		gzWriter *gzip.Writer
+	)
+
+	if strings.HasSuffix(args[0], ".gz") {
+		gzWriter = gzip.NewWriter(file)
+		out = gzWriter
+	}
+
+	slog.Info("Saving runner image", slog.String("image", image), slog.String("file", args[0]))
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	com := exec.Command(binary, "save", image)
+	com.Stdout = out
+	com.Stderr = os.Stderr
+
+	err = com.Run()
+	if err != nil {
+		return fmt.Errorf("error saving image %s: %w", image, err)
+	}
+
+	if gzWriter != nil {
+		err = gzWriter.Close()
+		if err != nil {
+			return fmt.Errorf("error compressing image: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ImageLoad loads a runner image from a tarball, possibly gzip compressed.
+func ImageLoad(_ *cobra.Command, args []string) error {
+	slog.Debug("Running workflow image load")
+
+	binary, err := exec.LookPath("docker")
+	if err != nil {
+		return fmt.Errorf("docker binary not found: %w", err)
+	}
+
+	slog.Info("Loading runner image", slog.String("file", args[0]))
+
+	// docker load handles compressed tarballs itself
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	com := exec.Command(binary, "load", "--input", args[0])
+	com.Stdout = os.Stdout
+	com.Stderr = os.Stderr
+
+	err = com.Run()
+	if err != nil {
+		return fmt.Errorf("error loading image from %s: %w", args[0], err)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/config"
+	"github.com/kemadev/kemutil/internal/gitrepo"
+	"github.com/spf13/cobra"
+)
+
+var ErrConfigFlagInvalid = errors.New("invalid flag default in configuration")
+
+var (
+	// Env is a flag to set runner containers environment variables, as KEY=VALUE, or KEY to
+	// forward the host value.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Env []string
+	// Volumes is a flag to mount extra docker volumes in runner containers.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Volumes []string
+	// Network is a flag to set runner containers docker network mode.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Network string
+	// CPUs is a flag to limit the number of CPUs runner containers may use.
+	//nolint:gochecknoglobals // Cobra flags are global
+	CPUs string
+	// Memory is a flag to limit runner containers memory.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Memory string
+)
+
+// loadConfig loads workflow configuration, and sets flags not set on the command line to their
+// configured default.
+func loadConfig(cmd *cobra.Command) (config.Workflow, error) {
+	conf, err := config.Load()
+	if err != nil {
+		return config.Workflow{}, fmt.Errorf("error loading config: %w", err)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(conf.Workflow.Flags)) {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			slog.Debug("Configured flag does not apply to command, skipping", slog.String("flag", name))
+
+			continue
+		}
+
+		if flag.Changed {
+			slog.Debug("Flag set on command line, ignoring configured default", slog.String("flag", name))
+
+			continue
+		}
+
+		err := flag.Value.Set(conf.Workflow.Flags[name])
+		if err != nil {
+			return config.Workflow{}, fmt.Errorf("%s: %w: %w", name, ErrConfigFlagInvalid, err)
+		}
+
+		slog.Debug("Flag set from configuration", slog.String("flag", name), slog.String("value", flag.Value.String()))
+	}
+
+	if Registry == "" {
+		Registry = conf.Workflow.Registry
+	}
+
+	return conf.Workflow, nil
+}
+
+// containerOptionsArgs returns docker arguments setting runner containers environment, volumes,
+// network, and limits. Command line flags take precedence over conf.
+func containerOptionsArgs(conf config.Workflow) ([]string, error) {
+	args := []string{}
+
+	hostEnv := os.Environ()
+
+	for _, pattern := range conf.Env {
+		for _, entry := range hostEnv {
+			name, _, _ := strings.Cut(entry, "=")
+
+			matched, err := path.Match(pattern, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid env pattern %q: %w", pattern, err)
+			}
+
+			if matched {
+				// Value is read from host environment by docker, keeping it out of arguments
+				args = append(args, "-e", name)
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(conf.EnvValues)) {
+		args = append(args, "-e", name+"="+conf.EnvValues[name])
+	}
+
+	// Set last, as docker keeps the last value of repeated variables
+	for _, env := range Env {
+		args = append(args, "-e", env)
+	}
+
+	repoRoot, err := gitrepo.Root()
+	if err != nil {
+		repoRoot = "."
+	}
+
+	for _, volume := range conf.Volumes {
+		resolved, err := resolveVolume(volume, repoRoot)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, "-v", resolved)
+	}
+
+	for _, volume := range Volumes {
+		resolved, err := resolveVolume(volume, ".")
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, "-v", resolved)
+	}
+
+	for _, option := range []struct {
+		name  string
+		flag  string
+		value string
+	}{
+		{name: "--network", flag: Network, value: conf.Network},
+		{name: "--cpus", flag: CPUs, value: conf.CPUs},
+		{name: "--memory", flag: Memory, value: conf.Memory},
+	} {
+		value := firstNonEmpty(option.flag, option.value)
+		if value != "" {
+			args = append(args, option.name, value)
+		}
+	}
+
+	return args, nil
+}
+
+// resolveVolume returns volume specification with relative and home-relative host paths made
+// absolute, relative ones against base. Named volumes are left untouched.
+func resolveVolume(spec string, base string) (string, error) {
+	source, rest, _ := strings.Cut(spec, ":")
+
+	switch {
+	case source == "~" || strings.HasPrefix(source, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error getting home directory: %w", err)
+		}
+
+		source = filepath.Join(home, strings.TrimPrefix(source, "~"))
+	case source == "." || source == ".." || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../"):
+		abs, err := filepath.Abs(filepath.Join(base, source))
+		if err != nil {
+			return "", fmt.Errorf("error getting absolute path: %w", err)
+		}
+
+		source = abs
+	default:
+		return spec, nil
+	}
+
+	if rest == "" {
+		return source, nil
+	}
+
+	return source + ":" + rest, nil
+}
@@ -0,0 +1,559 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// ReportDirEnvVarKey is the runner environment variable holding the directory checks write reports to.
+	ReportDirEnvVarKey = "RUNNER_REPORT_DIR"
+	// reportDirContainerPath is the path the report directory is mounted at in the runner container.
+	reportDirContainerPath = "/run/kemutil/reports"
+	// sarifVersion is the version of emitted SARIF files.
+	sarifVersion = "2.1.0"
+	// sarifSchema is the schema of emitted SARIF files.
+	sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// Severities, ordered from most to least severe.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityNote    = "note"
+)
+
+var ErrReportFormatUnknown = errors.New("unknown report format")
+
+var (
+	// ReportDir is a flag to set the directory checks write reports to.
+	//nolint:gochecknoglobals // Cobra flags are global
+	ReportDir string
+	// SarifOutput is a flag to set the path of the merged SARIF file.
+	//nolint:gochecknoglobals // Cobra flags are global
+	SarifOutput string
+)
+
+// finding is a single issue reported by a check.
+type finding struct {
+	Tool     string
+	Rule     string
+	Severity string
+	File     string
+	Line     int
+	Column   int
+	Message  string
+}
+
+// reportDirArgs creates a directory for the reports of this run in [ReportDir], so that reports of
+// previous runs are not merged into its summary, and returns docker arguments exposing it to the
+// runner, along with its path.
+func reportDirArgs(binary string, image string) ([]string, string, error) {
+	err := requireRunnerContract(binary, image, ReportDirEnvVarKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("error setting up reports: %w", err)
+	}
+
+	dir, err := filepath.Abs(ReportDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting absolute path: %w", err)
+	}
+
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, "", fmt.Errorf("error creating report directory: %w", err)
+	}
+
+	runDir, err := os.MkdirTemp(dir, time.Now().UTC().Format("20060102T150405Z")+"-")
+	if err != nil {
+		return nil, "", fmt.Errorf("error creating run report directory: %w", err)
+	}
+
+	slog.Debug("Created run report directory", slog.String("path", runDir))
+
+	return []string{
+		"-v",
+		runDir + ":" + reportDirContainerPath + ":Z",
+		"-e",
+		ReportDirEnvVarKey + "=" + reportDirContainerPath,
+	}, runDir, nil
+}
+
+// processReports collects reports written to runDir, prints a summary, and writes the merged
+// SARIF file if requested.
+func processReports(runDir string) error {
+	findings, err := collectReports(runDir, SarifOutput)
+	if err != nil {
+		return err
+	}
+
+	err = printReportSummary(findings)
+	if err != nil {
+		return err
+	}
+
+	if SarifOutput != "" {
+		err := writeSarif(SarifOutput, findings)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Wrote merged SARIF report", slog.String("path", SarifOutput))
+	}
+
+	return nil
+}
+
+// collectReports parses all SARIF, JUnit and JSON reports found in dir, except output, the path
+// of the merged SARIF file.
+func collectReports(dir string, output string) ([]finding, error) {
+	findings := []finding{}
+
+	if output != "" {
+		abs, err := filepath.Abs(output)
+		if err != nil {
+			return nil, fmt.Errorf("error getting absolute path: %w", err)
+		}
+
+		output = abs
+	}
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || path == output {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading report: %w", err)
+		}
+
+		tool := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+
+		var parsed []finding
+
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".sarif":
+			parsed, err = parseSarif(content)
+		case ".xml":
+			parsed, err = parseJUnit(content, tool)
+		case ".json":
+			parsed, err = parseJSONReport(content, tool)
+		default:
+			slog.Debug("Ignoring report with unknown extension", slog.String("path", path))
+
+			return nil
+		}
+
+		if err != nil {
+			slog.Warn("Ignoring unparsable report", slog.String("path", path), slog.String("error", err.Error()))
+
+			return nil
+		}
+
+		findings = append(findings, parsed...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error collecting reports: %w", err)
+	}
+
+	return findings, nil
+}
+
+// repoRelative converts paths reported from within the runner container to repository-relative paths.
+func repoRelative(path string) string {
+	path = strings.TrimPrefix(path, "file://")
+	path = strings.TrimPrefix(path, containerWorkspace+"/")
+
+	return strings.TrimPrefix(path, "./")
+}
+
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "error", "critical", "high", "fatal", "failure":
+		return SeverityError
+	case "note", "info", "low", "none":
+		return SeverityNote
+	default:
+		return SeverityWarning
+	}
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema,omitempty"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name  string      `json:"name"`
+			Rules []sarifRule `json:"rules,omitempty"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID  string `json:"ruleId,omitempty"`
+	Level   string `json:"level,omitempty"`
+	Message struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+func parseSarif(content []byte) ([]finding, error) {
+	report := sarifLog{}
+
+	err := json.Unmarshal(content, &report)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SARIF report: %w", err)
+	}
+
+	findings := []finding{}
+
+	for _, run := range report.Runs {
+		for _, result := range run.Results {
+			f := finding{
+				Tool:     run.Tool.Driver.Name,
+				Rule:     result.RuleID,
+				Severity: normalizeSeverity(result.Level),
+				Message:  result.Message.Text,
+			}
+
+			if len(result.Locations) > 0 {
+				location := result.Locations[0].PhysicalLocation
+				f.File = repoRelative(location.ArtifactLocation.URI)
+
+				if location.Region != nil {
+					f.Line = location.Region.StartLine
+					f.Column = location.Region.StartColumn
+				}
+			}
+
+			findings = append(findings, f)
+		}
+	}
+
+	return findings, nil
+}
+
+type junitTestCase struct {
+	Name      string `xml:"name,attr"`
+	ClassName string `xml:"classname,attr"`
+	File      string `xml:"file,attr"`
+	Line      int    `xml:"line,attr"`
+	Failures  []struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	} `xml:"failure"`
+	Errors []struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	} `xml:"error"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	File      string           `xml:"file,attr"`
+	TestCases []junitTestCase  `xml:"testcase"`
+	Suites    []junitTestSuite `xml:"testsuite"`
+}
+
+func parseJUnit(content []byte, tool string) ([]finding, error) {
+	// Root is either <testsuites> or a single <testsuite>, both decode into a suite
+	root := junitTestSuite{}
+
+	err := xml.Unmarshal(content, &root)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JUnit report: %w", err)
+	}
+
+	findings := []finding{}
+
+	var walk func(suite junitTestSuite)
+
+	walk = func(suite junitTestSuite) {
+		for _, testCase := range suite.TestCases {
+			file := repoRelative(firstNonEmpty(testCase.File, suite.File))
+			rule := strings.Trim(testCase.ClassName+"."+testCase.Name, ".")
+
+			for _, failure := range testCase.Failures {
+				findings = append(findings, finding{
+					Tool:     tool,
+					Rule:     rule,
+					Severity: SeverityError,
+					File:     file,
+					Line:     testCase.Line,
+					Message:  strings.TrimSpace(firstNonEmpty(failure.Message, failure.Text)),
+				})
+			}
+
+			for _, testErr := range testCase.Errors {
+				findings = append(findings, finding{
+					Tool:     tool,
+					Rule:     rule,
+					Severity: SeverityError,
+					File:     file,
+					Line:     testCase.Line,
+					Message:  strings.TrimSpace(firstNonEmpty(testErr.Message, testErr.Text)),
+				})
+			}
+		}
+
+		for _, child := range suite.Suites {
+			walk(child)
+		}
+	}
+
+	walk(root)
+
+	return findings, nil
+}
+
+// parseJSONReport parses SARIF reports with a .json extension, golangci-lint JSON reports, and
+// generic lists of findings with file, line, column, severity, rule and message fields.
+func parseJSONReport(content []byte, tool string) ([]finding, error) {
+	probe := map[string]json.RawMessage{}
+
+	err := json.Unmarshal(content, &probe)
+	if err == nil {
+		if _, ok := probe["runs"]; ok {
+			return parseSarif(content)
+		}
+
+		if _, ok := probe["Issues"]; ok {
+			return parseGolangciLint(content)
+		}
+	}
+
+	generic := []struct {
+		Tool     string `json:"tool"`
+		File     string `json:"file"`
+		Line     int    `json:"line"`
+		Column   int    `json:"column"`
+		Severity string `json:"severity"`
+		Rule     string `json:"rule"`
+		Message  string `json:"message"`
+	}{}
+
+	err = json.Unmarshal(content, &generic)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReportFormatUnknown, err)
+	}
+
+	findings := make([]finding, 0, len(generic))
+
+	for _, item := range generic {
+		findings = append(findings, finding{
+			Tool:     firstNonEmpty(item.Tool, tool),
+			Rule:     item.Rule,
+			Severity: normalizeSeverity(item.Severity),
+			File:     repoRelative(item.File),
+			Line:     item.Line,
+			Column:   item.Column,
+			Message:  item.Message,
+		})
+	}
+
+	return findings, nil
+}
+
+func parseGolangciLint(content []byte) ([]finding, error) {
+	report := struct {
+		Issues []struct {
+			FromLinter string `json:"FromLinter"`
+			Text       string `json:"Text"`
+			Severity   string `json:"Severity"`
+			Pos        struct {
+				Filename string `json:"Filename"`
+				Line     int    `json:"Line"`
+				Column   int    `json:"Column"`
+			} `json:"Pos"`
+		} `json:"Issues"`
+	}{}
+
+	err := json.Unmarshal(content, &report)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing golangci-lint report: %w", err)
+	}
+
+	findings := make([]finding, 0, len(report.Issues))
+
+	for _, issue := range report.Issues {
+		findings = append(findings, finding{
+			Tool:     "golangci-lint",
+			Rule:     issue.FromLinter,
+			Severity: normalizeSeverity(issue.Severity),
+			File:     repoRelative(issue.Pos.Filename),
+			Line:     issue.Pos.Line,
+			Column:   issue.Pos.Column,
+			Message:  issue.Text,
+		})
+	}
+
+	return findings, nil
+}
+
+// printReportSummary prints findings grouped by file, then by severity.
+func printReportSummary(findings []finding) error {
+	if len(findings) == 0 {
+		fmt.Println("No findings reported")
+
+		return nil
+	}
+
+	byFile := map[string][]finding{}
+	totals := map[string]int{}
+
+	for _, f := range findings {
+		byFile[f.File] = append(byFile[f.File], f)
+		totals[f.Severity]++
+	}
+
+	severities := []string{SeverityError, SeverityWarning, SeverityNote}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for _, file := range slices.Sorted(maps.Keys(byFile)) {
+		fileFindings := byFile[file]
+		slices.SortStableFunc(fileFindings, func(a, b finding) int {
+			return slices.Index(severities, a.Severity) - slices.Index(severities, b.Severity)
+		})
+
+		fmt.Fprintf(writer, "%s\n", firstNonEmpty(file, "(no file)"))
+
+		for _, f := range fileFindings {
+			fmt.Fprintf(
+				writer,
+				"  %s\t%d:%d\t%s\t%s\t%s\n",
+				f.Severity,
+				f.Line,
+				f.Column,
+				f.Tool,
+				f.Rule,
+				f.Message,
+			)
+		}
+	}
+
+	fmt.Fprintf(
+		writer,
+		"\n%d findings in %d files: %d errors, %d warnings, %d notes\n",
+		len(findings),
+		len(byFile),
+		totals[SeverityError],
+		totals[SeverityWarning],
+		totals[SeverityNote],
+	)
+
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing report summary: %w", err)
+	}
+
+	return nil
+}
+
+// writeSarif writes findings to path as a single SARIF file, with one run per tool.
+func writeSarif(path string, findings []finding) error {
+	byTool := map[string][]finding{}
+	for _, f := range findings {
+		byTool[f.Tool] = append(byTool[f.Tool], f)
+	}
+
+	report := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{},
+	}
+
+	for _, tool := range slices.Sorted(maps.Keys(byTool)) {
+		run := sarifRun{Results: []sarifResult{}}
+		run.Tool.Driver.Name = tool
+		rules := map[string]struct{}{}
+
+		for _, f := range byTool[tool] {
+			result := sarifResult{
+				RuleID: f.Rule,
+				Level:  f.Severity,
+			}
+			result.Message.Text = f.Message
+
+			if f.File != "" {
+				location := sarifLocation{}
+				location.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(f.File)
+
+				if f.Line > 0 {
+					location.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+				}
+
+				result.Locations = []sarifLocation{location}
+			}
+
+			if f.Rule != "" {
+				rules[f.Rule] = struct{}{}
+			}
+
+			run.Results = append(run.Results, result)
+		}
+
+		for _, rule := range slices.Sorted(maps.Keys(rules)) {
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: rule})
+		}
+
+		report.Runs = append(report.Runs, run)
+	}
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling SARIF report: %w", err)
+	}
+
+	err = os.WriteFile(path, content, 0o644)
+	if err != nil {
+		return fmt.Errorf("error writing SARIF report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,453 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// checksStatusEnvVarKey is the environment variable holding the path child processes write checks
+// status to, when running on several repositories.
+const checksStatusEnvVarKey = "KEMUTIL_CHECKS_STATUS_FILE"
+
+// Checks and repositories statuses.
+const (
+	statusPending = "pending"
+	statusRunning = "running"
+	statusPass    = "pass"
+	statusFail    = "FAIL"
+	statusCached  = "cached"
+)
+
+var (
+	ErrNoRepoMatched = errors.New("no repository matched")
+	ErrReposFailed   = errors.New("some repositories failed")
+)
+
+var (
+	// Repos is a flag to run on several repositories, given as a glob or a manifest file.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Repos string
+	// Concurrency is a flag to set how many repositories are processed at once.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Concurrency int
+)
+
+// repoRun is the state of a run on a repository.
+type repoRun struct {
+	name    string
+	dir     string
+	logPath string
+	status  string
+	start   time.Time
+	elapsed time.Duration
+	checks  map[string]string
+}
+
+// resolveRepos returns the repositories designated by spec, either a manifest file listing one
+// path or glob per line, relative to the manifest, or a glob.
+func resolveRepos(spec string) ([]string, error) {
+	patterns := []string{spec}
+
+	info, err := os.Stat(spec)
+	if err == nil && !info.IsDir() {
+		patterns, err = readManifest(spec)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	repos := []string{}
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repositories pattern %q: %w", pattern, err)
+		}
+
+		for _, match := range matches {
+			_, err := os.Stat(filepath.Join(match, ".git"))
+			if err != nil {
+				slog.Debug("Not a repository root, skipping", slog.String("path", match))
+
+				continue
+			}
+
+			if !slices.Contains(repos, match) {
+				repos = append(repos, match)
+			}
+		}
+	}
+
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("%q: %w", spec, ErrNoRepoMatched)
+	}
+
+	return repos, nil
+}
+
+// readManifest returns the patterns listed in manifest, skipping blank lines and comments.
+func readManifest(manifest string) ([]string, error) {
+	file, err := os.Open(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("error opening manifest: %w", err)
+	}
+	defer file.Close()
+
+	patterns := []string{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(manifest), line)
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+
+	return patterns, nil
+}
+
+// childArgs returns command line arguments of the current process, minus repositories flags.
+func childArgs() []string {
+	args := []string{}
+	skipNext := false
+
+	for _, arg := range os.Args[1:] {
+		if skipNext {
+			skipNext = false
+
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(arg, "=")
+		if name == "--repos" || name == "--concurrency" {
+			skipNext = !hasValue
+
+			continue
+		}
+
+		args = append(args, arg)
+	}
+
+	return args
+}
+
+// runRepos runs the current command in each repository designated by [Repos], displaying
+// progress, then a pass/fail matrix.
+func runRepos() error {
+	dirs, err := resolveRepos(Repos)
+	if err != nil {
+		return err
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("error getting executable path: %w", err)
+	}
+
+	logDir, err := os.MkdirTemp("", "kemutil-repos-")
+	if err != nil {
+		return fmt.Errorf("error creating logs directory: %w", err)
+	}
+
+	runs := make([]*repoRun, 0, len(dirs))
+	for i, dir := range dirs {
+		runs = append(runs, &repoRun{
+			name:    dir,
+			dir:     dir,
+			logPath: filepath.Join(logDir, fmt.Sprintf("%03d-%s.log", i, filepath.Base(dir))),
+			status:  statusPending,
+		})
+	}
+
+	args := childArgs()
+
+	slog.Debug("Running on repositories", slog.Any("repos", dirs), slog.Any("args", args))
+
+	var mutex sync.Mutex
+
+	board := newDashboard(runs, &mutex)
+	board.start()
+
+	concurrency := max(Concurrency, 1)
+	semaphore := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+
+	for _, run := range runs {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			mutex.Lock()
+			run.status = statusRunning
+			run.start = time.Now()
+			mutex.Unlock()
+			board.changed(run)
+
+			status, checks := runRepo(self, args, run)
+
+			mutex.Lock()
+			run.status = status
+			run.checks = checks
+			run.elapsed = time.Since(run.start)
+			mutex.Unlock()
+			board.changed(run)
+		}()
+	}
+
+	wg.Wait()
+	board.stop()
+
+	err = printReposMatrix(runs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "Logs: %s\n", logDir)
+
+	failed := []string{}
+
+	for _, run := range runs {
+		if run.status != statusPass {
+			failed = append(failed, run.name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(failed, ", "), ErrReposFailed)
+	}
+
+	return nil
+}
+
+// runRepo runs kemutil with args in the repository of run, and returns its status, along with
+// the status of each check if reported.
+func runRepo(self string, args []string, run *repoRun) (string, map[string]string) {
+	logFile, err := os.Create(run.logPath)
+	if err != nil {
+		slog.Error("Error creating log file", slog.String("repo", run.name), slog.String("error", err.Error()))
+
+		return statusFail, nil
+	}
+	defer logFile.Close()
+
+	statusPath := strings.TrimSuffix(run.logPath, ".log") + ".json"
+
+	// nosemgrep: gitlab.gosec.G204-1 // The executable is kemutil itself
+	com := exec.Command(self, args...)
+	com.Dir = run.dir
+	com.Stdout = logFile
+	com.Stderr = logFile
+	com.Env = append(os.Environ(), checksStatusEnvVarKey+"="+statusPath)
+
+	runErr := com.Run()
+
+	checks := map[string]string{}
+
+	content, err := os.ReadFile(statusPath)
+	if err == nil {
+		err = json.Unmarshal(content, &checks)
+		if err != nil {
+			slog.Warn("Error reading checks status", slog.String("repo", run.name), slog.String("error", err.Error()))
+		}
+	}
+
+	if runErr != nil {
+		return statusFail, checks
+	}
+
+	return statusPass, checks
+}
+
+// writeChecksStatus writes the status of each check to the file set by the parent process, if any.
+func writeChecksStatus(statuses map[string]string) {
+	statusPath := os.Getenv(checksStatusEnvVarKey)
+	if statusPath == "" {
+		return
+	}
+
+	content, err := json.Marshal(statuses)
+	if err != nil {
+		slog.Warn("Error marshalling checks status", slog.String("error", err.Error()))
+
+		return
+	}
+
+	err = os.WriteFile(statusPath, content, 0o644)
+	if err != nil {
+		slog.Warn("Error writing checks status", slog.String("error", err.Error()))
+	}
+}
+
+// dashboard displays the status of each repository run, redrawn in place on terminals, and
+// printed on each change otherwise.
+type dashboard struct {
+	runs        []*repoRun
+	mutex       *sync.Mutex
+	interactive bool
+	drawn       int
+	done        chan struct{}
+	stopped     chan struct{}
+}
+
+func newDashboard(runs []*repoRun, mutex *sync.Mutex) *dashboard {
+	info, err := os.Stdout.Stat()
+
+	return &dashboard{
+		runs:        runs,
+		mutex:       mutex,
+		interactive: err == nil && info.Mode()&os.ModeCharDevice != 0,
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+}
+
+func (d *dashboard) start() {
+	if !d.interactive {
+		close(d.stopped)
+
+		return
+	}
+
+	go func() {
+		defer close(d.stopped)
+
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			d.draw()
+
+			select {
+			case <-d.done:
+				d.draw()
+
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (d *dashboard) stop() {
+	close(d.done)
+	<-d.stopped
+}
+
+// changed reports a status change of run, printed right away on non-interactive outputs.
+func (d *dashboard) changed(run *repoRun) {
+	if d.interactive {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	fmt.Fprintf(os.Stdout, "%s: %s%s\n", run.name, run.status, elapsedSuffix(run))
+}
+
+// draw redraws the status of all runs in place.
+func (d *dashboard) draw() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.drawn > 0 {
+		fmt.Fprintf(os.Stdout, "\033[%dA", d.drawn)
+	}
+
+	width := 0
+	for _, run := range d.runs {
+		width = max(width, len(run.name))
+	}
+
+	for _, run := range d.runs {
+		fmt.Fprintf(os.Stdout, "\033[2K%-*s  %s%s\n", width, run.name, run.status, elapsedSuffix(run))
+	}
+
+	d.drawn = len(d.runs)
+}
+
+func elapsedSuffix(run *repoRun) string {
+	switch run.status {
+	case statusRunning:
+		return fmt.Sprintf(" (%s)", time.Since(run.start).Round(time.Second))
+	case statusPass, statusFail:
+		return fmt.Sprintf(" (%s)", run.elapsed.Round(time.Second))
+	default:
+		return ""
+	}
+}
+
+// printReposMatrix prints the status of each check for each repository, along with the overall
+// repository status.
+func printReposMatrix(runs []*repoRun) error {
+	checks := []string{}
+
+	for _, check := range KnownChecks {
+		reported := slices.ContainsFunc(runs, func(run *repoRun) bool {
+			_, ok := run.checks[check.Name]
+
+			return ok
+		})
+		if reported {
+			checks = append(checks, check.Name)
+		}
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, strings.Join(slices.Concat([]string{"REPOSITORY"}, checks, []string{"RESULT"}), "\t"))
+
+	for _, run := range runs {
+		row := []string{run.name}
+
+		for _, check := range checks {
+			status, ok := run.checks[check]
+			if !ok {
+				status = "-"
+			}
+
+			row = append(row, status)
+		}
+
+		row = append(row, run.status)
+
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing repositories matrix: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,384 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/gitrepo"
+	"github.com/spf13/cobra"
+)
+
+// containerWorkspace is the path the repository is mounted at in runner containers.
+const containerWorkspace = "/src"
+
+var ErrJobFailed = errors.New("job failed")
+
+// Job is a flag to select the job to run, along with its dependencies.
+//
+//nolint:gochecknoglobals // Cobra flags are global
+var Job string
+
+// Run runs a GitHub Actions workflow locally, executing `run` steps in the runner container.
+func Run(cmd *cobra.Command, args []string) error {
+	slog.Debug("Running workflow run")
+
+	conf, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repoRoot, err := gitrepo.Root()
+	if err != nil {
+		return fmt.Errorf("error finding repository root: %w", err)
+	}
+
+	wfPath, err := findWorkflowFile(args[0], repoRoot)
+	if err != nil {
+		return err
+	}
+
+	wf, err := parseWorkflow(wfPath)
+	if err != nil {
+		return err
+	}
+
+	order, err := jobOrder(wf.Jobs, Job)
+	if err != nil {
+		return fmt.Errorf("error resolving jobs graph: %w", err)
+	}
+
+	errs := []error{}
+	for _, id := range order {
+		errs = append(errs, validateJob(id, wf.Jobs[id], wf.Defaults))
+	}
+
+	err = errors.Join(errs...)
+	if err != nil {
+		return fmt.Errorf("workflow %s cannot run locally: %w", wfPath, err)
+	}
+
+	binary, err := exec.LookPath("docker")
+	if err != nil {
+		return fmt.Errorf("docker binary not found: %w", err)
+	}
+
+	envArgs, err := runnerEnvArgs(cmd, conf)
+	if err != nil {
+		return err
+	}
+
+	ws, err := newWorkspace(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	runner := jobRunner{
+		binary:  binary,
+		envArgs: envArgs,
+		ws:      ws,
+		wf:      wf,
+	}
+
+	slog.Info("Running workflow", slog.String("workflow", wfPath), slog.Any("jobs", order))
+
+	// As on GitHub, jobs run unless their condition does not hold for the outcome of their needs,
+	// so that jobs meant to run after failures do
+	outcomes := map[string]jobOutcome{}
+	jobErrs := []error{}
+
+	for _, id := range order {
+		job := wf.Jobs[id]
+
+		cond, err := parseCondition(job.If)
+		if err != nil {
+			return fmt.Errorf("error parsing condition of job %q: %w", id, err)
+		}
+
+		needsSucceeded, needsFailed := needsOutcome(job.Needs, outcomes)
+
+		if (cond == conditionSuccess && !needsSucceeded) || (cond == conditionFailure && !needsFailed) {
+			slog.Info("Skipping job", slog.String("job", id))
+
+			outcomes[id] = jobOutcome{skipped: true, failedUpstream: needsFailed}
+
+			continue
+		}
+
+		err = runner.runMatrix(id, job)
+		if err != nil {
+			jobErrs = append(jobErrs, err)
+		}
+
+		outcomes[id] = jobOutcome{failed: err != nil, failedUpstream: needsFailed}
+	}
+
+	err = errors.Join(jobErrs...)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Workflow succeeded", slog.String("workflow", wfPath))
+
+	return nil
+}
+
+// jobOutcome is the outcome of a job, as seen by jobs needing it.
+type jobOutcome struct {
+	skipped bool
+	failed  bool
+	// failedUpstream is set if a job the job transitively needs failed.
+	failedUpstream bool
+}
+
+// needsOutcome returns whether all needs succeeded, and whether any of them, or of their own needs,
+// failed.
+func needsOutcome(needs []string, outcomes map[string]jobOutcome) (bool, bool) {
+	succeeded, failed := true, false
+
+	for _, need := range needs {
+		outcome := outcomes[need]
+		succeeded = succeeded && !outcome.skipped && !outcome.failed
+		failed = failed || outcome.failed || outcome.failedUpstream
+	}
+
+	return succeeded, failed
+}
+
+// jobRunner runs jobs of a workflow in runner containers.
+type jobRunner struct {
+	binary  string
+	envArgs []string
+	ws      workspace
+	wf      ghaWorkflow
+}
+
+// runMatrix runs all matrix combinations of a job, stopping at the first failure if the job
+// strategy is to fail fast.
+func (r jobRunner) runMatrix(id string, job ghaJob) error {
+	combinations, err := expandMatrix(job.Strategy)
+	if err != nil {
+		return fmt.Errorf("error expanding matrix of job %q: %w", id, err)
+	}
+
+	failFast := job.Strategy.FailFast == nil || *job.Strategy.FailFast
+	errs := []error{}
+
+	for _, combination := range combinations {
+		err := r.runJob(id, combination)
+		if err != nil {
+			errs = append(errs, err)
+
+			if failFast {
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// runJob runs a job matrix combination in a dedicated container, executing each step in it.
+func (r jobRunner) runJob(id string, combination map[string]any) error {
+	wf := r.wf
+	job := wf.Jobs[id]
+	name := strings.TrimSpace(firstNonEmpty(job.Name, id) + " " + matrixDisplayName(combination))
+
+	ctx := exprContext{
+		matrix: combination,
+		env:    map[string]string{},
+		github: map[string]string{
+			"workspace": r.ws.workdir,
+			"job":       id,
+			"workflow":  wf.Name,
+		},
+	}
+
+	env := map[string]string{
+		"CI":                "true",
+		"GITHUB_WORKSPACE":  r.ws.workdir,
+		"GITHUB_JOB":        id,
+		"GITHUB_WORKFLOW":   wf.Name,
+		"RUNNER_OS":         "Linux",
+		"RUNNER_TEMP":       "/tmp",
+		"RUNNER_TOOL_CACHE": "/tmp",
+	}
+
+	for _, layer := range []map[string]string{wf.Env, job.Env, job.Container.Env} {
+		for key, value := range layer {
+			expanded, err := ctx.expand(value)
+			if err != nil {
+				return fmt.Errorf("error expanding env %s of job %q: %w", key, name, err)
+			}
+
+			env[key] = expanded
+			ctx.env[key] = expanded
+		}
+	}
+
+	imageURL := getImageURL()
+
+	image := strings.TrimPrefix(imageURL.String(), "//")
+	if job.Container.Image != "" {
+		expanded, err := ctx.expand(job.Container.Image)
+		if err != nil {
+			return fmt.Errorf("error expanding container image of job %q: %w", name, err)
+		}
+
+		image = expanded
+	} else {
+		err := ensureImage(r.binary, image)
+		if err != nil {
+			return err
+		}
+	}
+
+	startArgs := []string{
+		"run",
+		"--detach",
+		"--rm",
+	}
+	startArgs = append(startArgs, r.ws.args()...)
+	startArgs = append(startArgs, "--entrypoint", "sleep")
+	startArgs = append(startArgs, r.envArgs...)
+	startArgs = append(startArgs, strings.Fields(job.Container.Options)...)
+	startArgs = append(startArgs, image, "infinity")
+
+	slog.Info("Starting job", slog.String("job", name), slog.String("image", image))
+	slog.Debug("Running command", slog.Any("binary", r.binary), slog.Any("baseArgs", startArgs))
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	startCmd := exec.Command(r.binary, startArgs...)
+	startCmd.Stderr = os.Stderr
+
+	out, err := startCmd.Output()
+	if err != nil {
+		return fmt.Errorf("error starting container for job %q: %w", name, err)
+	}
+
+	containerID := strings.TrimSpace(string(out))
+
+	defer func() {
+		// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+		err := exec.Command(r.binary, "rm", "--force", containerID).Run()
+		if err != nil {
+			slog.Warn("Error removing job container", slog.String("container", containerID), slog.String("error", err.Error()))
+		}
+	}()
+
+	failed := false
+
+	for pos, step := range job.Steps {
+		stepName := step.displayName(pos)
+
+		cond, err := parseCondition(step.If)
+		if err != nil {
+			return fmt.Errorf("error parsing condition of step %q: %w", stepName, err)
+		}
+
+		if (failed && cond == conditionSuccess) || (!failed && cond == conditionFailure) {
+			slog.Info("Skipping step", slog.String("job", name), slog.String("step", stepName))
+
+			continue
+		}
+
+		if step.Uses != "" {
+			slog.Info("Skipping checkout step, repository is mounted", slog.String("job", name), slog.String("step", stepName))
+
+			continue
+		}
+
+		slog.Info("Running step", slog.String("job", name), slog.String("step", stepName))
+
+		err = r.runStep(containerID, job, step, ctx, env)
+		if err != nil {
+			slog.Error("Step failed", slog.String("job", name), slog.String("step", stepName), slog.String("error", err.Error()))
+
+			failed = true
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("%q: %w", name, ErrJobFailed)
+	}
+
+	slog.Info("Job succeeded", slog.String("job", name))
+
+	return nil
+}
+
+// runStep executes a `run` step in the job container.
+func (r jobRunner) runStep(
+	containerID string,
+	job ghaJob,
+	step ghaStep,
+	ctx exprContext,
+	jobEnv map[string]string,
+) error {
+	env := maps.Clone(jobEnv)
+	stepCtx := ctx
+	stepCtx.env = maps.Clone(ctx.env)
+
+	for key, value := range step.Env {
+		expanded, err := stepCtx.expand(value)
+		if err != nil {
+			return fmt.Errorf("error expanding env %s: %w", key, err)
+		}
+
+		env[key] = expanded
+		stepCtx.env[key] = expanded
+	}
+
+	script, err := stepCtx.expand(step.Run)
+	if err != nil {
+		return fmt.Errorf("error expanding script: %w", err)
+	}
+
+	shell, err := shellArgs(firstNonEmpty(step.Shell, job.Defaults.Run.Shell, r.wf.Defaults.Run.Shell))
+	if err != nil {
+		return err
+	}
+
+	workdir := path.Join(
+		r.ws.workdir,
+		firstNonEmpty(step.WorkingDirectory, job.Defaults.Run.WorkingDirectory, r.wf.Defaults.Run.WorkingDirectory),
+	)
+
+	// Values are passed through the docker CLI environment rather than its arguments, so that
+	// secrets do not show in process listings
+	execArgs := []string{"exec", "--workdir", workdir}
+	environ := os.Environ()
+
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		execArgs = append(execArgs, "--env", key)
+		environ = append(environ, key+"="+env[key])
+	}
+
+	execArgs = append(execArgs, containerID)
+	execArgs = append(execArgs, shell...)
+	execArgs = append(execArgs, script)
+
+	slog.Debug("Running command", slog.Any("binary", r.binary), slog.Any("baseArgs", execArgs))
+
+	// nosemgrep: gitlab.gosec.G204-1 // The purpose of the command is to run workflow steps
+	com := exec.Command(r.binary, execArgs...)
+	com.Env = environ
+	com.Stdout = os.Stdout
+	com.Stderr = os.Stderr
+
+	err = com.Run()
+	if err != nil {
+		return fmt.Errorf("error running step: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,400 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kemadev/kemutil/internal/gitrepo"
+	"github.com/kemadev/kemutil/internal/pollwatch"
+)
+
+const (
+	// watchedFilesContainerPath is the path the watched files list is mounted at in the runner container.
+	watchedFilesContainerPath = "/run/kemutil/watched-files"
+	// watchPollInterval is the interval files are checked for changes at.
+	watchPollInterval = 500 * time.Millisecond
+)
+
+var (
+	// Watch is a flag to re-run checks when files change.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Watch bool
+	// Debounce is a flag to set how long files must stay unchanged before checks re-run.
+	//nolint:gochecknoglobals // Cobra flags are global
+	Debounce time.Duration
+)
+
+// watcher runs checks in a long-lived runner container, re-running those whose inputs changed.
+type watcher struct {
+	binary      string
+	image       string
+	containerID string
+	entrypoint  []string
+	checks      []Check
+	workdir     string
+	repoRoot    string
+	listPath    string
+	tty         bool
+	// restrict is whether the runner supports restricting checks to the watched files list
+	restrict bool
+	tracked  *trackedFiles
+}
+
+// trackedFiles caches the files watched under the working directory, so that polling only stats
+// them. The list is refreshed when a directory holding them changes, as when files are added.
+type trackedFiles struct {
+	files []string
+	// dirs maps directories holding files, and their ancestors up to the working directory, to
+	// their modification time
+	dirs map[string]time.Time
+}
+
+// watchChecks runs checks, then re-runs the relevant ones on files changes, until interrupted.
+// Files ignored by git are not watched.
+func watchChecks(binary string, baseArgs []string, image string, checks []Check) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("error getting current working directory: %w", err)
+	}
+
+	repoRoot, err := gitrepo.RootFromPath(workdir)
+	if err != nil {
+		return fmt.Errorf("error finding repository root: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(workdir))
+
+	listPath, err := cachePath("watched-files", hex.EncodeToString(sum[:])[:16])
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(listPath), 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+
+	err = os.WriteFile(listPath, []byte{}, 0o644)
+	if err != nil {
+		return fmt.Errorf("error writing watched files list: %w", err)
+	}
+
+	entrypoint, err := imageEntrypoint(binary, image)
+	if err != nil {
+		return err
+	}
+
+	// Container is kept running between runs, so that checks start right away
+	startArgs := slices.Concat(
+		[]string{"run", "--detach"},
+		slices.DeleteFunc(slices.Clone(baseArgs[1:]), func(arg string) bool {
+			return arg == "--tty"
+		}),
+		[]string{"-v", listPath + ":" + watchedFilesContainerPath + ":ro,Z", "--entrypoint", "sleep", image, "infinity"},
+	)
+
+	slog.Debug("Starting watch container", slog.Any("binary", binary), slog.Any("baseArgs", startArgs))
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	startCmd := exec.Command(binary, startArgs...)
+	startCmd.Stderr = os.Stderr
+
+	out, err := startCmd.Output()
+	if err != nil {
+		return fmt.Errorf("error starting watch container: %w", err)
+	}
+
+	containerID := strings.TrimSpace(string(out))
+
+	defer func() {
+		// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+		err := exec.Command(binary, "rm", "--force", containerID).Run()
+		if err != nil {
+			slog.Warn("Error removing watch container", slog.String("container", containerID), slog.String("error", err.Error()))
+		}
+	}()
+
+	restrict := true
+
+	err = requireRunnerContract(binary, image, ChangedFilesEnvVarKey)
+	if err != nil {
+		slog.Warn("Re-running checks on all files instead of changed ones", slog.String("error", err.Error()))
+
+		restrict = false
+	}
+
+	info, err := os.Stdin.Stat()
+
+	w := watcher{
+		binary:      binary,
+		image:       image,
+		containerID: containerID,
+		entrypoint:  entrypoint,
+		checks:      checks,
+		workdir:     workdir,
+		repoRoot:    repoRoot,
+		listPath:    listPath,
+		tty:         err == nil && info.Mode()&os.ModeCharDevice != 0,
+		restrict:    restrict,
+		tracked:     &trackedFiles{},
+	}
+
+	return w.loop(ctx)
+}
+
+// imageEntrypoint returns the entrypoint of image.
+func imageEntrypoint(binary string, image string) ([]string, error) {
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	out, err := exec.Command(binary, "image", "inspect", "--format", "{{json .Config.Entrypoint}}", image).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting runner image entrypoint: %w", err)
+	}
+
+	entrypoint := []string{}
+
+	err = json.Unmarshal(out, &entrypoint)
+	if err != nil || len(entrypoint) == 0 {
+		return nil, fmt.Errorf("error parsing runner image entrypoint %q: %w", strings.TrimSpace(string(out)), err)
+	}
+
+	return entrypoint, nil
+}
+
+// loop runs all checks, then watches files until ctx is done.
+func (w watcher) loop(ctx context.Context) error {
+	w.run(w.checks, nil)
+
+	poller, err := pollwatch.New(w.snapshot, Debounce)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Watching files for changes, press Ctrl+C to stop", slog.String("dir", w.workdir))
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping watch")
+
+			return nil
+		case <-ticker.C:
+		}
+
+		files, err := poller.Poll()
+		if err != nil {
+			slog.Warn("Error listing files", slog.String("error", err.Error()))
+
+			continue
+		}
+
+		if len(files) == 0 {
+			continue
+		}
+
+		relevant := slices.DeleteFunc(slices.Clone(w.checks), func(check Check) bool {
+			return !slices.ContainsFunc(files, check.tracks)
+		})
+
+		if len(relevant) == 0 {
+			slog.Debug("No check tracks changed files", slog.Any("files", files))
+
+			continue
+		}
+
+		slog.Info("Files changed, re-running checks", slog.Any("files", files))
+
+		w.run(relevant, files)
+
+		// Files modified by checks, such as in fix mode, must not trigger another run
+		err = poller.Reset()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// snapshot returns the state of files under the working directory, keyed by repository-relative path.
+func (w watcher) snapshot() (map[string]pollwatch.State, error) {
+	if w.tracked.stale() {
+		err := w.tracked.refresh(w.repoRoot, w.workdir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	states := make(map[string]pollwatch.State, len(w.tracked.files))
+
+	for _, file := range w.tracked.files {
+		info, err := os.Stat(filepath.Join(w.repoRoot, file))
+		if err != nil {
+			continue
+		}
+
+		states[file] = pollwatch.StateOf(info)
+	}
+
+	return states, nil
+}
+
+// stale reports whether files may have been added or removed since the last refresh.
+func (t *trackedFiles) stale() bool {
+	if t.dirs == nil {
+		return true
+	}
+
+	for dir, modTime := range t.dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refresh lists files of the repository at repoRoot under workdir, that are tracked, or untracked
+// but not ignored.
+func (t *trackedFiles) refresh(repoRoot string, workdir string) error {
+	files, err := gitrepo.TrackedFiles(repoRoot)
+	if err != nil {
+		return fmt.Errorf("error listing tracked files: %w", err)
+	}
+
+	t.files = []string{}
+	t.dirs = map[string]time.Time{}
+
+	dirs := map[string]struct{}{workdir: {}}
+
+	for _, file := range files {
+		path := filepath.Join(repoRoot, file)
+
+		rel, err := filepath.Rel(workdir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		t.files = append(t.files, file)
+
+		for dir := filepath.Dir(path); dir != workdir && strings.HasPrefix(dir, workdir); dir = filepath.Dir(dir) {
+			dirs[dir] = struct{}{}
+		}
+	}
+
+	for dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			continue
+		}
+
+		t.dirs[dir] = info.ModTime()
+	}
+
+	slog.Debug("Listed watched files", slog.Int("files", len(t.files)), slog.Int("dirs", len(t.dirs)))
+
+	return nil
+}
+
+// run runs checks in the watch container, restricted to files if not nil, and logs a summary.
+func (w watcher) run(checks []Check, files []string) {
+	execEnv := []string{}
+
+	if files != nil && w.restrict {
+		relFiles := make([]string, 0, len(files))
+
+		for _, file := range files {
+			rel, err := filepath.Rel(w.workdir, filepath.Join(w.repoRoot, file))
+			if err != nil {
+				continue
+			}
+
+			_, err = os.Stat(filepath.Join(w.workdir, rel))
+			if err != nil {
+				continue
+			}
+
+			relFiles = append(relFiles, filepath.ToSlash(rel))
+		}
+
+		// Written in place, as the file is bind mounted
+		err := os.WriteFile(w.listPath, []byte(strings.Join(relFiles, "\n")+"\n"), 0o644)
+		if err != nil {
+			slog.Warn("Error writing watched files list", slog.String("error", err.Error()))
+		} else {
+			execEnv = append(execEnv, "-e", ChangedFilesEnvVarKey+"="+watchedFilesContainerPath)
+		}
+	}
+
+	var snapshot *fixSnapshot
+
+	if Fix {
+		var err error
+
+		snapshot, err = snapshotFix()
+		if err != nil {
+			slog.Warn("Error recording files state before fix run", slog.String("error", err.Error()))
+		}
+	}
+
+	failed := []string{}
+
+	for _, check := range checks {
+		args := []string{"exec", "--interactive"}
+		if w.tty {
+			args = append(args, "--tty")
+		}
+
+		args = slices.Concat(args, execEnv, []string{w.containerID}, w.entrypoint, []string{check.Name})
+		if Fix && check.Fixable {
+			args = append(args, "--fix")
+		}
+
+		slog.Info("Running check", slog.String("check", check.Name))
+
+		err := runCommand(w.binary, args)
+		if err != nil {
+			slog.Error("Check failed", slog.String("check", check.Name), slog.String("error", err.Error()))
+
+			failed = append(failed, check.Name)
+
+			continue
+		}
+
+		slog.Info("Check succeeded", slog.String("check", check.Name))
+	}
+
+	if snapshot != nil {
+		err := finishFix(w.binary, w.image, snapshot)
+		if err != nil {
+			slog.Warn("Error handling files modified by fix run", slog.String("error", err.Error()))
+		}
+	}
+
+	if len(failed) > 0 {
+		slog.Error("Some checks failed", slog.Any("checks", failed))
+
+		return
+	}
+
+	slog.Info("All checks succeeded", slog.Int("checks", len(checks)))
+}
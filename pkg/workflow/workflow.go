@@ -10,15 +10,26 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"syscall"
 
 	"github.com/kemadev/ci-cd/pkg/auth"
-	"github.com/kemadev/go-framework/pkg/git"
+	"github.com/kemadev/kemutil/internal/config"
+	"github.com/kemadev/kemutil/internal/credential"
 	"github.com/spf13/cobra"
 )
 
-var ErrRepoURLInvalid = errors.New("repository URL is invalid")
+// ErrRepoURLInvalid is returned when the repository remote URL cannot be parsed while exporting
+// netrc.
+//
+// Deprecated: netrc export moved to credential providers, this alias of the error they return is
+// kept for compatibility.
+//
+//nolint:gochecknoglobals // Kept for compatibility
+var ErrRepoURLInvalid = credential.ErrRepoURLInvalid
+
+var ErrFlagsIncompatible = errors.New("incompatible flags")
 
 var (
 	//nolint:gochecknoglobals // Used as a const
@@ -51,28 +62,108 @@ var (
 		"run",
 		"--rm",
 		"--interactive",
-		"--tty",
-		"-v",
-		".:/src:Z",
 	}
 )
 
+// dockerRunArgs returns base docker arguments mounting the current workspace, allocating a TTY
+// only when attached to a terminal, as is not the case when running from git hooks.
+func dockerRunArgs() ([]string, error) {
+	args := slices.Clone(dockerArgs)
+
+	info, err := os.Stdin.Stat()
+	if err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		args = append(args, "--tty")
+	}
+
+	ws, err := currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("Mounting workspace", slog.String("hostDir", ws.hostDir), slog.String("workdir", ws.workdir))
+
+	return append(args, ws.args()...), nil
+}
+
 func getImageURL() url.URL {
 	if Hot {
-		slog.Debug("Hot reload mode enabled", slog.String("imageUrl", ciImageDevURL.String()))
+		imageURL := applyRegistry(ciImageDevURL)
+
+		slog.Debug("Hot reload mode enabled", slog.String("imageUrl", imageURL.String()))
 
-		return ciImageDevURL
+		return imageURL
 	}
 
-	slog.Debug("Hot reload mode not enabled", slog.String("imageUrl", ciImageDevURL.String()))
+	imageURL := applyRegistry(ciImageProdURL)
 
-	return ciImageProdURL
+	slog.Debug("Hot reload mode not enabled", slog.String("imageUrl", imageURL.String()))
+
+	return imageURL
+}
+
+// runnerEnvArgs returns docker arguments setting the runner environment, variables, Go caches and
+// container options, according to flags and conf.
+func runnerEnvArgs(cmd *cobra.Command, conf config.Workflow) ([]string, error) {
+	args, err := containerOptionsArgs(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if RunnerDebug {
+		slog.Debug("Debug mode is enabled, adding debug flag to base arguments")
+
+		args = append(args, "-e", "RUNNER_DEBUG=1")
+	}
+
+	if cmd.Flag("silent").Value.String() == "true" {
+		slog.Debug("Silent mode is enabled, adding silent flag to base arguments")
+
+		args = append(args, "-e", "RUNNER_SILENT=1")
+	}
+
+	if ExportNetrc {
+		netrc, err := credential.RemoteNetrc()
+		if err != nil {
+			return nil, fmt.Errorf("error getting netrc: %w", err)
+		}
+
+		args = append(args, "-e", auth.NetrcEnvVarKey+"="+netrc)
+	}
+
+	cacheArgs, err := goCacheArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	args = append(args, cacheArgs...)
+	args = append(args, ownerArgs()...)
+
+	return args, nil
 }
 
 // Ci runs the CI workflows.
 func Ci(cmd *cobra.Command, _ []string) error {
+	return RunCi(cmd, ChangedOptions{Changed: Changed, Base: Base})
+}
+
+// RunCi runs the CI workflows on files selected by opts, other settings being read from flags.
+func RunCi(cmd *cobra.Command, opts ChangedOptions) error {
 	slog.Debug("Running workflow CI")
 
+	if Repos != "" {
+		return runRepos()
+	}
+
+	conf, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Reports are processed once checks are done, which never happens in watch mode
+	if ReportDir != "" && Watch {
+		return fmt.Errorf("--report-dir cannot be used with --watch: %w", ErrFlagsIncompatible)
+	}
+
 	imageURL := getImageURL()
 
 	binary, err := exec.LookPath("docker")
@@ -80,96 +171,164 @@ func Ci(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("docker binary not found: %w", err)
 	}
 
-	baseArgs := dockerArgs
-
-	if RunnerDebug {
-		slog.Debug("Debug mode is enabled, adding debug flag to base arguments")
+	envArgs, err := runnerEnvArgs(cmd, conf)
+	if err != nil {
+		return err
+	}
 
-		baseArgs = append(baseArgs, "-e", "RUNNER_DEBUG=1")
+	baseArgs, err := dockerRunArgs()
+	if err != nil {
+		return err
 	}
 
-	if cmd.Flag("silent").Value.String() == "true" {
-		slog.Debug("Silent mode is enabled, adding silent flag to base arguments")
+	baseArgs = append(baseArgs, envArgs...)
+
+	image := strings.TrimPrefix(imageURL.String(), "//")
 
-		baseArgs = append(baseArgs, "-e", "RUNNER_SILENT=1")
+	err = ensureImage(binary, image)
+	if err != nil {
+		return err
 	}
 
-	gitToken := ""
+	// Container options, such as environment, volumes and limits, and changed files are part of
+	// cache keys, as they change what checks see
+	cacheInputs := resolvedEnvArgs(envArgs)
 
-	if ExportNetrc {
-		machine, err := git.NewGitService().GetGitBasePath()
+	if opts.enabled() {
+		err := requireRunnerContract(binary, image, ChangedFilesEnvVarKey)
 		if err != nil {
-			return fmt.Errorf("error getting git repository: %w", err)
+			return fmt.Errorf("error checking changed files only: %w", err)
 		}
 
-		machineParts := strings.Split(machine, "/")
-		if len(machineParts) < 1 {
-			return fmt.Errorf("error parsing git repository URL: %w", ErrRepoURLInvalid)
-		}
+		changedArgs, files, err := changedFilesArgs(opts)
+		if errors.Is(err, ErrNoChangedFiles) {
+			slog.Info("No changed files, nothing to check")
 
-		machine = machineParts[0]
+			return nil
+		}
 
-		ghBinary, err := exec.LookPath("gh")
 		if err != nil {
-			return fmt.Errorf("error finding gh binary: %w", err)
+			return err
 		}
 
-		ghArgs := []string{
-			"auth",
-			"token",
+		baseArgs = append(baseArgs, changedArgs...)
+		cacheInputs = append(cacheInputs, files...)
+	}
+
+	useCache := !NoCache
+	reportRunDir := ""
+
+	if ReportDir != "" {
+		reportArgs, runDir, err := reportDirArgs(binary, image)
+		if err != nil {
+			return err
 		}
 
-		// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
-		com := exec.Command(ghBinary, ghArgs...)
+		baseArgs = append(baseArgs, reportArgs...)
+		reportRunDir = runDir
+
+		if useCache {
+			slog.Debug("Reports requested, disabling CI results cache so that all checks report")
+
+			useCache = false
+		}
+	}
 
-		token, err := com.CombinedOutput()
+	if Watch {
+		checks, err := SelectChecks(Only, Skip)
 		if err != nil {
-			return fmt.Errorf("error getting git token command output: %w", err)
+			return err
 		}
 
-		gitToken = string(token)
-		netrc := `machine ` + machine + `
-login git
-password ` + string(token) + `
-`
-		baseArgs = append(baseArgs, "-e", auth.NetrcEnvVarKey+"="+netrc)
+		return watchChecks(binary, baseArgs, image, checks)
 	}
 
-	baseArgs = append(baseArgs, strings.TrimPrefix(imageURL.String(), "//"))
+	var snapshot *fixSnapshot
 
-	baseArgs = append(baseArgs, "ci")
 	if Fix {
-		baseArgs = append(baseArgs, "--fix")
+		snapshot, err = snapshotFix()
+		if err != nil {
+			return err
+		}
 	}
 
-	slog.Debug("Running command", slog.Any("binary", binary), slog.Any("baseArgs", func() []string {
-		if gitToken == "" {
-			return baseArgs
+	var runErr error
+
+	switch {
+	case len(Only) > 0 || len(Skip) > 0 || useCache:
+		checks, err := SelectChecks(Only, Skip)
+		if err != nil {
+			return err
 		}
 
-		redactedArgs := make([]string, len(baseArgs))
+		var cache *resultCache
 
-		for pos, arg := range baseArgs {
-			redacted := arg
-			if strings.Contains(arg, gitToken) {
-				redactedParts := strings.Split(redacted, gitToken)
-				if len(redactedParts) != 2 {
-					return []string{"MALFORMED GIT TOKEN"}
-				}
+		if useCache {
+			cache, err = newResultCache(binary, image, cacheInputs...)
+			if err != nil {
+				slog.Warn("CI results cache is unavailable, running all checks", slog.String("error", err.Error()))
 
-				redacted = strings.Join(redactedParts, gitToken[0:(len(gitToken)/4)]+"...")
+				cache = nil
 			}
+		}
+
+		runErr = runChecks(binary, baseArgs, image, checks, cache)
+	case ReportDir != "" || Fix:
+		runErr = runCommand(binary, append(baseArgs, ciArgs(image)...))
+	default:
+		baseArgs = append(baseArgs, ciArgs(image)...)
 
-			redactedArgs[pos] = redacted
+		slog.Debug("Running command", slog.Any("binary", binary), slog.Any("baseArgs", baseArgs))
+
+		// nosemgrep: go.lang.security.audit.dangerous-syscall-exec.dangerous-syscall-exec // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+		err = syscall.Exec(binary, append([]string{binary}, baseArgs...), os.Environ())
+		if err != nil {
+			return fmt.Errorf("error running workflow ci command: %w", err)
 		}
 
-		return redactedArgs
-	}()))
+		return nil
+	}
 
-	// nosemgrep: go.lang.security.audit.dangerous-syscall-exec.dangerous-syscall-exec // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
-	err = syscall.Exec(binary, append([]string{binary}, baseArgs...), os.Environ())
+	if snapshot != nil {
+		err := finishFix(binary, image, snapshot)
+		if err != nil {
+			runErr = errors.Join(runErr, err)
+		}
+	}
+
+	if reportRunDir != "" {
+		err := processReports(reportRunDir)
+		if err != nil {
+			return errors.Join(runErr, err)
+		}
+	}
+
+	return runErr
+}
+
+// ciArgs returns the arguments running the full CI with image.
+func ciArgs(image string) []string {
+	args := []string{image, "ci"}
+	if Fix {
+		args = append(args, "--fix")
+	}
+
+	return args
+}
+
+// runCommand runs binary with args, attached to the standard streams.
+func runCommand(binary string, args []string) error {
+	slog.Debug("Running command", slog.Any("binary", binary), slog.Any("baseArgs", args))
+
+	// nosemgrep: gitlab.gosec.G204-1 // exec.LookPath() is used to locate the binary via $PATH, however we run on trusted developer machines
+	com := exec.Command(binary, args...)
+	com.Stdin = os.Stdin
+	com.Stdout = os.Stdout
+	com.Stderr = os.Stderr
+
+	err := com.Run()
 	if err != nil {
-		return fmt.Errorf("error running workflow ci command: %w", err)
+		return fmt.Errorf("error running command: %w", err)
 	}
 
 	return nil
@@ -179,6 +338,15 @@ password ` + string(token) + `
 func Custom(cmd *cobra.Command, args []string) error {
 	slog.Debug("Running workflow custom")
 
+	if Repos != "" {
+		return runRepos()
+	}
+
+	conf, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
 	imageURL := getImageURL()
 
 	binary, err := exec.LookPath("docker")
@@ -186,61 +354,26 @@ func Custom(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("docker binary not found: %w", err)
 	}
 
-	baseArgs := dockerArgs
-
-	if RunnerDebug {
-		slog.Debug("Debug mode is enabled, adding debug flag to base arguments")
-
-		baseArgs = append(baseArgs, "-e", "RUNNER_DEBUG=1")
+	envArgs, err := runnerEnvArgs(cmd, conf)
+	if err != nil {
+		return err
 	}
 
-	if cmd.Flag("silent").Value.String() == "true" {
-		slog.Debug("Silent mode is enabled, adding silent flag to base arguments")
-
-		baseArgs = append(baseArgs, "-e", "RUNNER_SILENT=1")
+	baseArgs, err := dockerRunArgs()
+	if err != nil {
+		return err
 	}
 
-	gitToken := ""
+	baseArgs = append(baseArgs, envArgs...)
 
-	if ExportNetrc {
-		machine, err := git.NewGitService().GetGitBasePath()
-		if err != nil {
-			return fmt.Errorf("error getting git repository: %w", err)
-		}
-
-		machineParts := strings.Split(machine, "/")
-		if len(machineParts) < 1 {
-			return fmt.Errorf("error parsing git repository URL: %w", ErrRepoURLInvalid)
-		}
-
-		machine = machineParts[0]
+	image := strings.TrimPrefix(imageURL.String(), "//")
 
-		ghBinary, err := exec.LookPath("gh")
-		if err != nil {
-			return fmt.Errorf("error finding gh binary: %w", err)
-		}
-
-		ghArgs := []string{
-			"auth",
-			"token",
-		}
-
-		com := exec.Command(ghBinary, ghArgs...)
-
-		token, err := com.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("error getting git token command output: %w", err)
-		}
-
-		gitToken = string(token)
-		netrc := `machine ` + machine + `
-login git
-password ` + string(token) + `
-`
-		baseArgs = append(baseArgs, "-e", auth.NetrcEnvVarKey+"="+netrc)
+	err = ensureImage(binary, image)
+	if err != nil {
+		return err
 	}
 
-	baseArgs = append(baseArgs, strings.TrimPrefix(imageURL.String(), "//"))
+	baseArgs = append(baseArgs, image)
 
 	baseArgs = append(baseArgs, args...)
 
@@ -248,31 +381,24 @@ password ` + string(token) + `
 		slog.Debug("Fix mode is enabled, adding fix flag to base arguments")
 
 		baseArgs = append(baseArgs, "--fix")
-	}
 
-	slog.Debug("Running command", slog.Any("binary", binary), slog.Any("baseArgs", func() []string {
-		if gitToken == "" {
-			return baseArgs
+		snapshot, err := snapshotFix()
+		if err != nil {
+			return err
 		}
 
-		redactedArgs := make([]string, len(baseArgs))
-
-		for pos, arg := range baseArgs {
-			redacted := arg
-			if strings.Contains(arg, gitToken) {
-				redactedParts := strings.Split(redacted, gitToken)
-				if len(redactedParts) != 2 {
-					return []string{"MALFORMED GIT TOKEN"}
-				}
-
-				redacted = strings.Join(redactedParts, gitToken[0:(len(gitToken)/4)]+"...")
-			}
+		// Files ownership and report are handled after the run, so the process must not be replaced
+		runErr := runCommand(binary, baseArgs)
 
-			redactedArgs[pos] = redacted
+		err = finishFix(binary, image, snapshot)
+		if err != nil {
+			return errors.Join(runErr, err)
 		}
 
-		return redactedArgs
-	}()))
+		return runErr
+	}
+
+	slog.Debug("Running command", slog.Any("binary", binary), slog.Any("baseArgs", baseArgs))
 
 	// nosemgrep: go.lang.security.audit.dangerous-syscall-exec.dangerous-syscall-exec // The purpose of the command is to run a custom command
 	err = syscall.Exec(binary, append([]string{binary}, baseArgs...), os.Environ())
@@ -0,0 +1,108 @@
+// Copyright 2025 kemadev
+// SPDX-License-Identifier: MPL-2.0
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kemadev/kemutil/internal/gitrepo"
+)
+
+// Scope is a flag to mount only the current directory in the runner container, instead of the
+// whole repository.
+//
+//nolint:gochecknoglobals // Cobra flags are global
+var Scope bool
+
+// workspace is the host directory mounted in the runner container, along with the container
+// directory matching the host current one.
+type workspace struct {
+	// hostDir is the host directory mounted at [containerWorkspace].
+	hostDir string
+	// workdir is the container working directory.
+	workdir string
+	// gitDirs are git directories outside of hostDir, mounted at the same path so that git
+	// references to them resolve in the container.
+	gitDirs []string
+}
+
+// newWorkspace returns the workspace matching dir. The repository root, that of the superproject
+// for submodules, is mounted, unless [Scope] is set or dir is not in a repository, in which case
+// dir itself is.
+func newWorkspace(dir string) (workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return workspace{}, fmt.Errorf("error getting absolute path: %w", err)
+	}
+
+	scoped := workspace{
+		hostDir: dir,
+		workdir: containerWorkspace,
+		gitDirs: []string{},
+	}
+
+	if Scope {
+		return scoped, nil
+	}
+
+	layout, err := gitrepo.LayoutFromPath(dir)
+	if errors.Is(err, gitrepo.ErrNotInGitRepo) {
+		slog.Debug("Not in a git repository, mounting current directory", slog.String("dir", dir))
+
+		return scoped, nil
+	}
+
+	if err != nil {
+		return workspace{}, fmt.Errorf("error getting repository layout: %w", err)
+	}
+
+	rel, err := filepath.Rel(layout.MountRoot, dir)
+	if err != nil {
+		return workspace{}, fmt.Errorf("error getting relative path: %w", err)
+	}
+
+	return workspace{
+		hostDir: layout.MountRoot,
+		workdir: path.Join(containerWorkspace, filepath.ToSlash(rel)),
+		gitDirs: layout.GitDirs,
+	}, nil
+}
+
+// currentWorkspace returns the workspace matching the current directory.
+func currentWorkspace() (workspace, error) {
+	workdir, err := os.Getwd()
+	if err != nil {
+		return workspace{}, fmt.Errorf("error getting current working directory: %w", err)
+	}
+
+	return newWorkspace(workdir)
+}
+
+// args returns docker arguments mounting the workspace and setting the working directory.
+func (w workspace) args() []string {
+	args := []string{"-v", w.hostDir + ":" + containerWorkspace + ":Z"}
+
+	for _, gitDir := range w.gitDirs {
+		// Shared label, as git directories may be used by several worktrees at once
+		args = append(args, "-v", gitDir+":"+gitDir+":z")
+	}
+
+	return append(args, "--workdir", w.workdir)
+}
+
+// containerPath returns the container path of host path, and false if it is not mounted.
+func (w workspace) containerPath(hostPath string) (string, bool) {
+	rel, err := filepath.Rel(w.hostDir, hostPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return path.Join(containerWorkspace, filepath.ToSlash(rel)), true
+}